@@ -0,0 +1,99 @@
+package faust
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const redacted = "[REDACTED]"
+
+type AccessLogConfig struct {
+	// Logger defaults to slog.Default().
+	Logger *slog.Logger
+	// Level is the level requests are logged at, server errors are always
+	// logged at slog.LevelError.
+	Level slog.Level
+	// Params adds the validated parameter values to the log records.
+	Params bool
+	// Redact lists parameter names, case-insensitive, whose values are never
+	// logged. Parameters marked sensitive are always redacted.
+	Redact []string
+}
+
+// AccessLog logs every request to the endpoints of the API and its
+// subrouters, unless a subrouter sets its own configuration.
+func (api *API) AccessLog(config AccessLogConfig) *API {
+	if config.Logger == nil {
+		config.Logger = slog.Default()
+	}
+	api.accessLog = &config
+	return api
+}
+
+func (api *API) accessLogConfig() *AccessLogConfig {
+	for a := api; a != nil; a = a.parent {
+		if a.accessLog != nil {
+			return a.accessLog
+		}
+	}
+	return nil
+}
+
+// logger is the logger faust reports its own errors to.
+func (api *API) logger() *slog.Logger {
+	if config := api.accessLogConfig(); config != nil {
+		return config.Logger
+	}
+	return slog.Default()
+}
+
+func (e *Endpoint) logAccess(rw *responseWriter, r *http.Request, start time.Time) {
+	config := e.api.accessLogConfig()
+	if config == nil {
+		return
+	}
+	level := config.Level
+	if rw.Status() >= 500 {
+		level = slog.LevelError
+	}
+	ctx := r.Context()
+	if !config.Logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("method", e.Method),
+		slog.String("route", e.api.prefix()+e.Path),
+		slog.Int("status", rw.Status()),
+		slog.Int64("bytes", rw.bytes),
+		slog.Duration("latency", time.Since(start)),
+	}
+	if e.EndpointInfo.Name != "" {
+		attrs = append(attrs, slog.String("name", e.EndpointInfo.Name))
+	}
+	if id := RequestIDFrom(ctx); id != "" {
+		attrs = append(attrs, slog.String("request_id", id))
+	}
+	if state := stateFrom(ctx); config.Params && state != nil {
+		state.lock.Lock()
+		captured := state.params
+		state.lock.Unlock()
+		params := make([]any, 0, len(captured))
+		for _, param := range captured {
+			value := param.Value
+			for _, name := range config.Redact {
+				if strings.EqualFold(name, param.Name) {
+					value = redacted
+				}
+			}
+			params = append(params, slog.Any(param.In+"."+param.Name, value))
+		}
+		if len(params) > 0 {
+			attrs = append(attrs, slog.Group("params", params...))
+		}
+	}
+	config.Logger.LogAttrs(context.WithoutCancel(ctx), level, "request", attrs...)
+}
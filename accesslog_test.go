@@ -0,0 +1,114 @@
+package faust_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/param"
+	"log/slog"
+	"net/http"
+	"testing"
+)
+
+// accessLog returns an API logging as JSON and a function reading the records
+// logged so far.
+func accessLog(config faust.AccessLogConfig) (*faust.API, func(t *testing.T) []map[string]any) {
+	var buf bytes.Buffer
+	config.Logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	api := faust.New().AccessLog(config)
+	return api, func(t *testing.T) []map[string]any {
+		t.Helper()
+		var records []map[string]any
+		decoder := json.NewDecoder(&buf)
+		for decoder.More() {
+			var record map[string]any
+			if err := decoder.Decode(&record); err != nil {
+				t.Fatal(err)
+			}
+			records = append(records, record)
+		}
+		return records
+	}
+}
+
+func TestAccessLog(t *testing.T) {
+	api, records := accessLog(faust.AccessLogConfig{})
+	api.RequestID(faust.RequestIDConfig{})
+	api.Subrouter("/users").Post("/{id}", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Name("createUser")
+		param.Path[int](e, "id")
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			w.Write([]byte("hello"))
+		}
+	})
+	api.Get("/broken", func(e *faust.Endpoint) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+	send(api, "POST", "/users/7", map[string]string{"X-Request-ID": "req-1"})
+	send(api, "GET", "/broken", nil)
+
+	logged := records(t)
+	if len(logged) != 2 {
+		t.Fatalf("got %d records, want 2", len(logged))
+	}
+	want := map[string]any{
+		"level":      "INFO",
+		"msg":        "request",
+		"method":     "POST",
+		"route":      "/users/{id}",
+		"name":       "createUser",
+		"status":     float64(http.StatusCreated),
+		"bytes":      float64(5),
+		"request_id": "req-1",
+	}
+	for key, value := range want {
+		if logged[0][key] != value {
+			t.Errorf("%s: got %v, want %v", key, logged[0][key], value)
+		}
+	}
+	if _, ok := logged[0]["latency"]; !ok {
+		t.Error("no latency logged")
+	}
+	if _, ok := logged[0]["params"]; ok {
+		t.Error("params logged without AccessLogConfig.Params")
+	}
+	if logged[1]["level"] != "ERROR" || logged[1]["status"] != float64(http.StatusBadGateway) || logged[1]["bytes"] != float64(0) {
+		t.Errorf("server error: got %v", logged[1])
+	}
+}
+
+func TestAccessLogRedaction(t *testing.T) {
+	api, records := accessLog(faust.AccessLogConfig{Level: slog.LevelWarn, Params: true, Redact: []string{"EMAIL"}})
+	api.Get("/search", func(e *faust.Endpoint) http.HandlerFunc {
+		param.Query[string](e, "q")
+		param.Query[string](e, "email")
+		param.Header[string](e, "X-Token").Sensitive()
+		return func(w http.ResponseWriter, r *http.Request) {}
+	})
+	send(api, "GET", "/search?q=shoes&email=alice@example.com", map[string]string{"X-Token": "secret"})
+
+	logged := records(t)
+	if len(logged) != 1 {
+		t.Fatalf("got %d records, want 1", len(logged))
+	}
+	if logged[0]["level"] != "WARN" {
+		t.Errorf("got level %v, want WARN", logged[0]["level"])
+	}
+	params, _ := logged[0]["params"].(map[string]any)
+	want := map[string]any{
+		"query.q":        "shoes",
+		"query.email":    "[REDACTED]",
+		"header.X-Token": "[REDACTED]",
+	}
+	if len(params) != len(want) {
+		t.Errorf("got params %v, want %v", params, want)
+	}
+	for key, value := range want {
+		if params[key] != value {
+			t.Errorf("%s: got %v, want %v", key, params[key], value)
+		}
+	}
+}
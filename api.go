@@ -1,11 +1,13 @@
 package faust
 
 import (
-	"encoding/json"
-	"fmt"
+	"errors"
 	"github.com/gorilla/mux"
-	"github.com/nokusukun/faust/docgen"
+	"github.com/nokusukun/faust/trace"
 	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
 )
 
 func (api *API) Get(path string, handler func(e *Endpoint) http.HandlerFunc) *mux.Route {
@@ -29,28 +31,20 @@ func (api *API) Delete(path string, handler func(e *Endpoint) http.HandlerFunc)
 }
 
 func (api *API) Method(method, path string, handler func(e *Endpoint) http.HandlerFunc) *mux.Route {
+	api.checkNotBuilt()
 	endpoint := &Endpoint{
 		EndpointInfo: EndpointInfo{
 			Path:   path,
 			Method: method,
 		},
 		Params: []IParam{},
+		api:    api,
 	}
 	// This is where the magic happens
 	// Aka, this is where the endpoint parameter is discovered
 	endpoint.httpHandler = handler(endpoint)
 	api.Endpoints = append(api.Endpoints, endpoint)
-	return api.Mux.HandleFunc(path, func(w http.ResponseWriter, r *http.Request) {
-		if endpoint.Use(w, r) {
-			h := endpoint.httpHandler
-			// we want the middleware to be executed in reverse order
-			for i := len(endpoint.middlewares) - 1; i >= 0; i-- {
-				h = endpoint.middlewares[i](h).ServeHTTP
-			}
-			h(w, r)
-		}
-		go endpoint.Dispose(r)
-	}).Methods(method)
+	return api.Mux.HandleFunc(path, endpoint.ServeHTTP).Methods(method)
 }
 
 type APIContact struct {
@@ -81,7 +75,32 @@ type API struct {
 	Endpoints  []*Endpoint `json:"endpoints,omitempty"`
 	Mux        *mux.Router `json:"-"`
 	Subrouters []*API      `json:"subroutes,omitempty"`
-	built      bool
+	Authorizer Authorizer  `json:"-"`
+	// OnError writes the error responses of endpoints without an OnError of
+	// their own, subrouters inherit it from their parent.
+	OnError func(w http.ResponseWriter, r *http.Request, err error) `json:"-"`
+	// OnPanic is called with every panic recovered from an endpoint, panics
+	// are logged when it is not set.
+	OnPanic   func(r *http.Request, err *PanicError) `json:"-"`
+	cors      *CORSConfig
+	accessLog *AccessLogConfig
+	requestID *RequestIDConfig
+	metrics   *Metrics
+	tracer    *trace.Tracer
+	rateLimit *RateLimitPolicy
+	// rateLimitStore is the default store of the root API
+	rateLimitStore     *MemoryRateLimitStore
+	rateLimitStoreOnce sync.Once
+	timeout            time.Duration
+	tags               []string
+	docs               DocsConfig
+	viewers            []docsViewer
+	parent             *API
+	build              sync.Once
+	buildErr           error
+	// buildReport logs a failed automatic Build once
+	buildReport sync.Once
+	built       atomic.Bool
 }
 
 func New(info ...APIInfo) *API {
@@ -98,38 +117,30 @@ func New(info ...APIInfo) *API {
 }
 
 func (api *API) ServeHTTP(w http.ResponseWriter, r *http.Request) {
-	if !api.built {
-		api.Mux.HandleFunc("/docs.json", func(w http.ResponseWriter, r *http.Request) {
-			err := json.NewEncoder(w).Encode(api)
-			if err != nil {
-				fmt.Println(err)
-			}
-		}).Methods("GET")
-		api.Mux.HandleFunc("/docs.html", func(w http.ResponseWriter, r *http.Request) {
-			// TODO: rewrite this, this is a mess
-			jsonData, _ := json.MarshalIndent(api, "", "  ")
-			var apiDoc docgen.APIDoc
-			err := json.Unmarshal(jsonData, &apiDoc)
-			if err != nil {
-				fmt.Println("Error parsing JSON:", err)
-				return
-			}
-			html := docgen.GenerateHTML(apiDoc)
-
-			w.Header().Set("Content-Type", "text/html")
-			w.WriteHeader(200)
-			w.Write([]byte(html))
-		}).Methods("GET")
-		api.built = true
+	if err := api.Build(); err != nil {
+		api.buildReport.Do(func() {
+			api.logger().Error("building API", "error", err)
+		})
+		WriteError(w, r, NewError(http.StatusInternalServerError, "internal_error", errors.New("internal server error")))
+		return
 	}
 	api.Mux.ServeHTTP(w, r)
 }
 
+// Tags groups every endpoint of the API and its subrouters in the
+// documentation.
+func (api *API) Tags(tags ...string) *API {
+	api.tags = append(api.tags, tags...)
+	return api
+}
+
 func (api *API) Subrouter(path string) *API {
+	api.checkNotBuilt()
 	subApi := &API{
-		Path:  path,
-		isSub: true,
-		Mux:   api.Mux.PathPrefix(path).Subrouter(),
+		Path:   path,
+		isSub:  true,
+		parent: api,
+		Mux:    api.Mux.PathPrefix(path).Subrouter(),
 	}
 	api.Subrouters = append(api.Subrouters, subApi)
 	return subApi
@@ -0,0 +1,373 @@
+// Package apidiff compares two versions of the endpoint metadata of a faust
+// API, as served at /docs.json, and classifies the changes as breaking or
+// not for existing clients.
+package apidiff
+
+import (
+	"fmt"
+	"github.com/nokusukun/faust/docgen"
+	"github.com/nokusukun/faust/internal/pathvars"
+	"github.com/nokusukun/faust/internal/sorted"
+	"reflect"
+	"strings"
+)
+
+type Change struct {
+	Breaking bool `json:"breaking"`
+	// Endpoint is the method and path of the changed endpoint.
+	Endpoint string `json:"endpoint"`
+	// Location is the changed part of the endpoint, e.g. "query parameter
+	// sort" or "response 200 .items[].name", empty for the endpoint itself.
+	Location string `json:"location,omitempty"`
+	Message  string `json:"message"`
+}
+
+func (c Change) String() string {
+	kind := "non-breaking"
+	if c.Breaking {
+		kind = "BREAKING"
+	}
+	if c.Location == "" {
+		return fmt.Sprintf("%s %s: %s", kind, c.Endpoint, c.Message)
+	}
+	return fmt.Sprintf("%s %s: %s: %s", kind, c.Endpoint, c.Location, c.Message)
+}
+
+// HasBreaking reports whether any of the changes is breaking.
+func HasBreaking(changes []Change) bool {
+	for _, change := range changes {
+		if change.Breaking {
+			return true
+		}
+	}
+	return false
+}
+
+// Compare lists the changes from old to current, ordered by endpoint.
+// Endpoints are matched by method and path, ignoring the names of path
+// variables. Changes to what clients send are breaking when the API accepts
+// less than before: removed endpoints, new required parameters, narrower
+// types and removed enum values. Changes to responses are breaking when
+// clients may get something they didn't before: removed or optional fields,
+// wider types and new enum values.
+func Compare(old, current docgen.APIDoc) []Change {
+	oldEndpoints, newEndpoints := endpoints(old), endpoints(current)
+	var changes []Change
+	for _, key := range sorted.Keys(oldEndpoints, newEndpoints) {
+		oldEndpoint, inOld := oldEndpoints[key]
+		newEndpoint, inNew := newEndpoints[key]
+		switch {
+		case !inNew:
+			changes = append(changes, Change{Breaking: true, Endpoint: name(oldEndpoint), Message: "endpoint removed"})
+		case !inOld:
+			changes = append(changes, Change{Endpoint: name(newEndpoint), Message: "endpoint added"})
+		default:
+			c := &comparison{endpoint: name(newEndpoint)}
+			c.endpoints(oldEndpoint, newEndpoint)
+			changes = append(changes, c.changes...)
+		}
+	}
+	return changes
+}
+
+func endpoints(doc docgen.APIDoc) map[string]docgen.Endpoint {
+	endpoints := map[string]docgen.Endpoint{}
+	for _, endpoint := range doc.Root().AllEndpoints() {
+		endpoints[endpoint.Method+" "+pathvars.Unnamed(endpoint.FullPath)] = endpoint
+	}
+	return endpoints
+}
+
+func name(endpoint docgen.Endpoint) string {
+	return endpoint.Method + " " + endpoint.FullPath
+}
+
+type comparison struct {
+	endpoint string
+	changes  []Change
+}
+
+func (c *comparison) add(breaking bool, location, format string, args ...any) {
+	c.changes = append(c.changes, Change{
+		Breaking: breaking,
+		Endpoint: c.endpoint,
+		Location: location,
+		Message:  fmt.Sprintf(format, args...),
+	})
+}
+
+func (c *comparison) endpoints(old, current docgen.Endpoint) {
+	c.authorization(old.Authorization, current.Authorization)
+
+	oldParams, newParams := parameters(old), parameters(current)
+	for _, key := range sorted.Keys(oldParams, newParams) {
+		oldParam, inOld := oldParams[key]
+		newParam, inNew := newParams[key]
+		switch {
+		case !inNew:
+			c.add(false, location(oldParam), "removed")
+		case !inOld && newParam.Optional:
+			c.add(false, location(newParam), "optional parameter added")
+		case !inOld:
+			c.add(true, location(newParam), "required parameter added")
+		default:
+			if oldParam.In == "path" && oldParam.Name != newParam.Name {
+				c.add(false, location(newParam), "renamed from %s", oldParam.Name)
+			}
+			if oldParam.Optional && !newParam.Optional {
+				c.add(true, location(newParam), "became required")
+			} else if !oldParam.Optional && newParam.Optional {
+				c.add(false, location(newParam), "became optional")
+			}
+			c.schema(location(newParam), "", oldParam.Schema, newParam.Schema, true)
+		}
+	}
+
+	oldResponses, newResponses := responses(old), responses(current)
+	for _, key := range sorted.Keys(oldResponses, newResponses) {
+		oldResponse, inOld := oldResponses[key]
+		newResponse, inNew := newResponses[key]
+		where := "response " + key
+		switch {
+		case !inNew:
+			c.add(true, where, "removed")
+		case !inOld:
+			c.add(false, where, "added")
+		case oldResponse.Schema != nil && newResponse.Schema == nil:
+			c.add(true, where, "schema removed")
+		case oldResponse.Schema == nil && newResponse.Schema != nil:
+			c.add(false, where, "schema added")
+		case oldResponse.Schema != nil:
+			c.schema(where, "", *oldResponse.Schema, *newResponse.Schema, false)
+		}
+	}
+}
+
+func (c *comparison) authorization(old, current *docgen.Authorization) {
+	if old == nil && current != nil {
+		c.add(true, "", "authorization required")
+		return
+	}
+	if old != nil && current == nil {
+		c.add(false, "", "authorization no longer required")
+		return
+	}
+	if old == nil {
+		return
+	}
+	for _, scope := range added(old.Scopes, current.Scopes) {
+		c.add(true, "", "scope %q required", scope)
+	}
+	for _, scope := range added(current.Scopes, old.Scopes) {
+		c.add(false, "", "scope %q no longer required", scope)
+	}
+	for _, role := range added(old.Roles, current.Roles) {
+		c.add(true, "", "role %q required", role)
+	}
+	for _, role := range added(current.Roles, old.Roles) {
+		c.add(false, "", "role %q no longer required", role)
+	}
+}
+
+// schema compares the schemas of the value at path, e.g. ".items[].name", in
+// parent. Request values are sent by clients and response values are read by
+// them.
+func (c *comparison) schema(parent, path string, old, current docgen.Schema, request bool) {
+	where := parent
+	if path != "" {
+		where = parent + " " + path
+	}
+	oldType, newType := typeName(old), typeName(current)
+	if oldType != newType {
+		switch {
+		case accepts(newType, oldType) && !accepts(oldType, newType):
+			c.add(!request, where, "type widened from %s to %s", oldType, newType)
+		case accepts(oldType, newType) && !accepts(newType, oldType):
+			c.add(request, where, "type narrowed from %s to %s", oldType, newType)
+		default:
+			c.add(true, where, "type changed from %s to %s", oldType, newType)
+			return
+		}
+	}
+
+	switch {
+	case len(old.Enum) > 0 && len(current.Enum) == 0:
+		c.add(!request, where, "no longer restricted to %s", formatValues(old.Enum))
+	case len(old.Enum) == 0 && len(current.Enum) > 0:
+		c.add(request, where, "restricted to %s", formatValues(current.Enum))
+	case len(old.Enum) > 0:
+		if removed := addedValues(current.Enum, old.Enum); len(removed) > 0 {
+			c.add(request, where, "enum values %s removed", formatValues(removed))
+		}
+		if added := addedValues(old.Enum, current.Enum); len(added) > 0 {
+			c.add(!request, where, "enum values %s added", formatValues(added))
+		}
+	}
+
+	if old.Items != nil && current.Items != nil {
+		c.schema(parent, path+"[]", *old.Items, *current.Items, request)
+	}
+	if old.AdditionalProperties != nil && current.AdditionalProperties != nil {
+		c.schema(parent, path+"[key]", *old.AdditionalProperties, *current.AdditionalProperties, request)
+	}
+	if old.Properties == nil || current.Properties == nil {
+		return
+	}
+	oldRequired, newRequired := set(old.Required), set(current.Required)
+	for _, property := range sorted.Keys(old.Properties, current.Properties) {
+		oldProperty, inOld := old.Properties[property]
+		newProperty, inNew := current.Properties[property]
+		fieldPath := path + "." + property
+		field := parent + " " + fieldPath
+		switch {
+		case !inNew:
+			// clients may still send it, it's ignored
+			c.add(!request, field, "field removed")
+		case !inOld && request && newRequired[property]:
+			c.add(true, field, "required field added")
+		case !inOld:
+			c.add(false, field, "field added")
+		default:
+			if oldRequired[property] && !newRequired[property] {
+				c.add(!request, field, "became optional")
+			} else if !oldRequired[property] && newRequired[property] {
+				c.add(request, field, "became required")
+			}
+			c.schema(parent, fieldPath, oldProperty, newProperty, request)
+		}
+	}
+}
+
+func parameters(endpoint docgen.Endpoint) map[string]docgen.Parameter {
+	// path parameters are matched by position like the paths
+	positions := map[string]int{}
+	for i, name := range pathvars.Names(endpoint.FullPath) {
+		positions[name] = i
+	}
+	parameters := map[string]docgen.Parameter{}
+	for _, parameter := range endpoint.Parameters {
+		key := parameter.In + " " + parameter.Name
+		switch parameter.In {
+		case "path":
+			if position, ok := positions[parameter.Name]; ok {
+				key = fmt.Sprintf("path %d", position)
+			}
+		case "jsonbody", "body":
+			// an endpoint reads a single body, its name is only documentation
+			key = parameter.In
+		}
+		parameters[key] = parameter
+	}
+	return parameters
+}
+
+func location(parameter docgen.Parameter) string {
+	switch parameter.In {
+	case "jsonbody":
+		return "JSON body"
+	case "body":
+		return "body"
+	}
+	return parameter.In + " parameter " + parameter.Name
+}
+
+func responses(endpoint docgen.Endpoint) map[string]docgen.Response {
+	responses := map[string]docgen.Response{}
+	for _, response := range endpoint.Responses {
+		responses[fmt.Sprint(response.Status)] = response
+	}
+	return responses
+}
+
+// typeName is the Go kind of the schema, or date-time for times. Arrays and
+// slices are both JSON arrays.
+func typeName(schema docgen.Schema) string {
+	switch {
+	case schema.Format == "date-time":
+		return "date-time"
+	case schema.Type == "array":
+		return "slice"
+	}
+	return schema.Type
+}
+
+type number struct {
+	kind string
+	bits int
+}
+
+var numbers = map[string]number{
+	"int": {"int", 64}, "int8": {"int", 8}, "int16": {"int", 16}, "int32": {"int", 32}, "int64": {"int", 64},
+	"uint": {"uint", 64}, "uint8": {"uint", 8}, "uint16": {"uint", 16}, "uint32": {"uint", 32}, "uint64": {"uint", 64},
+	"float32": {"float", 32}, "float64": {"float", 64},
+}
+
+// accepts reports whether every value of type narrow is a value of type wide.
+func accepts(wide, narrow string) bool {
+	if wide == narrow || wide == "interface" {
+		return true
+	}
+	if wide == "string" && narrow == "date-time" {
+		return true
+	}
+	w, wideIsNumber := numbers[wide]
+	n, narrowIsNumber := numbers[narrow]
+	if !wideIsNumber || !narrowIsNumber {
+		return false
+	}
+	switch {
+	case w.kind == n.kind:
+		return w.bits >= n.bits
+	case w.kind == "int" && n.kind == "uint":
+		return w.bits > n.bits
+	case w.kind == "float":
+		// JSON numbers, the precision of large integers aside
+		return true
+	}
+	return false
+}
+
+func set(values []string) map[string]bool {
+	set := map[string]bool{}
+	for _, value := range values {
+		set[value] = true
+	}
+	return set
+}
+
+// added returns the values of current that aren't in old.
+func added(old, current []string) []string {
+	have := set(old)
+	var added []string
+	for _, value := range current {
+		if !have[value] {
+			added = append(added, value)
+		}
+	}
+	return added
+}
+
+func addedValues(old, current []any) []any {
+	var added []any
+	for _, value := range current {
+		found := false
+		for _, existing := range old {
+			if reflect.DeepEqual(value, existing) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			added = append(added, value)
+		}
+	}
+	return added
+}
+
+func formatValues(values []any) string {
+	formatted := make([]string, len(values))
+	for i, value := range values {
+		formatted[i] = fmt.Sprintf("%#v", value)
+	}
+	return strings.Join(formatted, ", ")
+}
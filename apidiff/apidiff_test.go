@@ -0,0 +1,191 @@
+package apidiff_test
+
+import (
+	"github.com/nokusukun/faust/apidiff"
+	"github.com/nokusukun/faust/docgen"
+	"reflect"
+	"testing"
+)
+
+func doc(endpoints ...docgen.Endpoint) docgen.APIDoc {
+	return docgen.APIDoc{Path: "/", Endpoints: endpoints}
+}
+
+func get(path string, parameters []docgen.Parameter, responses ...docgen.Response) docgen.Endpoint {
+	return docgen.Endpoint{Method: "GET", Path: path, Parameters: parameters, Responses: responses}
+}
+
+func query(name string, optional bool, schema docgen.Schema) docgen.Parameter {
+	return docgen.Parameter{In: "query", Name: name, Optional: optional, Schema: schema}
+}
+
+func ok(schema docgen.Schema) docgen.Response {
+	return docgen.Response{Status: 200, Schema: &schema}
+}
+
+var (
+	str   = docgen.Schema{Type: "string"}
+	small = docgen.Schema{Type: "int32"}
+	large = docgen.Schema{Type: "int64"}
+	sorts = docgen.Schema{Type: "string", Enum: []any{"name", "date"}}
+	names = docgen.Schema{Type: "string", Enum: []any{"name"}}
+)
+
+func pet(required ...string) docgen.Schema {
+	return docgen.Schema{Type: "struct", Required: required, Properties: map[string]docgen.Schema{"name": str}}
+}
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		old, cur docgen.Endpoint
+		want     []string
+	}{
+		{"unchanged",
+			get("/pets", []docgen.Parameter{query("sort", true, sorts)}, ok(pet("name"))),
+			get("/pets", []docgen.Parameter{query("sort", true, sorts)}, ok(pet("name"))),
+			nil},
+		{"path variable renamed",
+			get("/pets/{id:[0-9]+}", []docgen.Parameter{{In: "path", Name: "id", Schema: large}}),
+			get("/pets/{pet:[0-9]+}", []docgen.Parameter{{In: "path", Name: "pet", Schema: large}}),
+			[]string{"non-breaking GET /pets/{pet:[0-9]+}: path parameter pet: renamed from id"}},
+		{"path variable with a nested brace pattern renamed",
+			get("/codes/{code:[a-z]{3}}", nil),
+			get("/codes/{c:[a-z]{3}}", nil),
+			nil},
+		{"required parameter added",
+			get("/pets", nil),
+			get("/pets", []docgen.Parameter{query("owner", false, str)}),
+			[]string{"BREAKING GET /pets: query parameter owner: required parameter added"}},
+		{"optional parameter added",
+			get("/pets", nil),
+			get("/pets", []docgen.Parameter{query("owner", true, str)}),
+			[]string{"non-breaking GET /pets: query parameter owner: optional parameter added"}},
+		{"parameter removed",
+			get("/pets", []docgen.Parameter{query("owner", true, str)}),
+			get("/pets", nil),
+			[]string{"non-breaking GET /pets: query parameter owner: removed"}},
+		{"parameter became required",
+			get("/pets", []docgen.Parameter{query("owner", true, str)}),
+			get("/pets", []docgen.Parameter{query("owner", false, str)}),
+			[]string{"BREAKING GET /pets: query parameter owner: became required"}},
+		{"parameter became optional",
+			get("/pets", []docgen.Parameter{query("owner", false, str)}),
+			get("/pets", []docgen.Parameter{query("owner", true, str)}),
+			[]string{"non-breaking GET /pets: query parameter owner: became optional"}},
+		{"parameter enum value removed",
+			get("/pets", []docgen.Parameter{query("sort", true, sorts)}),
+			get("/pets", []docgen.Parameter{query("sort", true, names)}),
+			[]string{`BREAKING GET /pets: query parameter sort: enum values "date" removed`}},
+		{"parameter enum value added",
+			get("/pets", []docgen.Parameter{query("sort", true, names)}),
+			get("/pets", []docgen.Parameter{query("sort", true, sorts)}),
+			[]string{`non-breaking GET /pets: query parameter sort: enum values "date" added`}},
+		{"parameter restricted to an enum",
+			get("/pets", []docgen.Parameter{query("sort", true, str)}),
+			get("/pets", []docgen.Parameter{query("sort", true, names)}),
+			[]string{`BREAKING GET /pets: query parameter sort: restricted to "name"`}},
+		{"parameter type widened",
+			get("/pets", []docgen.Parameter{query("limit", true, small)}),
+			get("/pets", []docgen.Parameter{query("limit", true, large)}),
+			[]string{"non-breaking GET /pets: query parameter limit: type widened from int32 to int64"}},
+		{"parameter type narrowed",
+			get("/pets", []docgen.Parameter{query("limit", true, large)}),
+			get("/pets", []docgen.Parameter{query("limit", true, small)}),
+			[]string{"BREAKING GET /pets: query parameter limit: type narrowed from int64 to int32"}},
+		{"response enum value added",
+			get("/pets", nil, ok(names)),
+			get("/pets", nil, ok(sorts)),
+			[]string{`BREAKING GET /pets: response 200: enum values "date" added`}},
+		{"response type widened",
+			get("/pets", nil, ok(small)),
+			get("/pets", nil, ok(large)),
+			[]string{"BREAKING GET /pets: response 200: type widened from int32 to int64"}},
+		{"response type changed",
+			get("/pets", nil, ok(str)),
+			get("/pets", nil, ok(large)),
+			[]string{"BREAKING GET /pets: response 200: type changed from string to int64"}},
+		{"response removed",
+			get("/pets", nil, ok(str)),
+			get("/pets", nil),
+			[]string{"BREAKING GET /pets: response 200: removed"}},
+		{"response added",
+			get("/pets", nil),
+			get("/pets", nil, ok(str)),
+			[]string{"non-breaking GET /pets: response 200: added"}},
+		{"response field became optional",
+			get("/pets", nil, ok(pet("name"))),
+			get("/pets", nil, ok(pet())),
+			[]string{"BREAKING GET /pets: response 200 .name: became optional"}},
+		{"response field removed",
+			get("/pets", nil, ok(pet())),
+			get("/pets", nil, ok(docgen.Schema{Type: "struct", Properties: map[string]docgen.Schema{}})),
+			[]string{"BREAKING GET /pets: response 200 .name: field removed"}},
+		{"response field added",
+			get("/pets", nil, ok(docgen.Schema{Type: "struct", Properties: map[string]docgen.Schema{}})),
+			get("/pets", nil, ok(pet())),
+			[]string{"non-breaking GET /pets: response 200 .name: field added"}},
+		{"required body field added",
+			get("/pets", []docgen.Parameter{{In: "jsonbody", Name: "pet", Schema: docgen.Schema{Type: "struct", Properties: map[string]docgen.Schema{}}}}),
+			get("/pets", []docgen.Parameter{{In: "jsonbody", Name: "body", Schema: pet("name")}}),
+			[]string{"BREAKING GET /pets: JSON body .name: required field added"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, change := range apidiff.Compare(doc(test.old), doc(test.cur)) {
+				got = append(got, change.String())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCompareEndpoints(t *testing.T) {
+	old := doc(get("/pets", nil), get("/owners", nil))
+	cur := doc(get("/pets", nil), get("/stores", nil))
+	changes := apidiff.Compare(old, cur)
+	want := []apidiff.Change{
+		{Breaking: true, Endpoint: "GET /owners", Message: "endpoint removed"},
+		{Endpoint: "GET /stores", Message: "endpoint added"},
+	}
+	if !reflect.DeepEqual(changes, want) {
+		t.Errorf("got %+v, want %+v", changes, want)
+	}
+	if !apidiff.HasBreaking(changes) || apidiff.HasBreaking(changes[1:]) {
+		t.Error("HasBreaking doesn't report the removed endpoint alone")
+	}
+}
+
+func TestCompareAuthorization(t *testing.T) {
+	open := get("/pets", nil)
+	readers, writers := open, open
+	readers.Authorization = &docgen.Authorization{Scopes: []string{"pets:read"}}
+	writers.Authorization = &docgen.Authorization{Scopes: []string{"pets:write"}, Roles: []string{"admin"}}
+	tests := []struct {
+		name     string
+		old, cur docgen.Endpoint
+		want     []string
+	}{
+		{"required", open, readers, []string{"BREAKING GET /pets: authorization required"}},
+		{"no longer required", readers, open, []string{"non-breaking GET /pets: authorization no longer required"}},
+		{"scopes and roles changed", readers, writers, []string{
+			`BREAKING GET /pets: scope "pets:write" required`,
+			`non-breaking GET /pets: scope "pets:read" no longer required`,
+			`BREAKING GET /pets: role "admin" required`,
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, change := range apidiff.Compare(doc(test.old), doc(test.cur)) {
+				got = append(got, change.String())
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %q, want %q", got, test.want)
+			}
+		})
+	}
+}
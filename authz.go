@@ -0,0 +1,114 @@
+package faust
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// Principal is the authenticated caller of a request, placed on the request
+// context by an authentication middleware through WithPrincipal.
+type Principal interface {
+	Scopes() []string
+	Roles() []string
+}
+
+type principalKey struct{}
+
+func WithPrincipal(r *http.Request, principal Principal) *http.Request {
+	return r.WithContext(context.WithValue(r.Context(), principalKey{}, principal))
+}
+
+func PrincipalFrom(r *http.Request) Principal {
+	principal, _ := r.Context().Value(principalKey{}).(Principal)
+	return principal
+}
+
+// Requirements are the scopes and roles a caller needs to use an endpoint.
+type Requirements struct {
+	Scopes []string `json:"scopes,omitempty"`
+	Roles  []string `json:"roles,omitempty"`
+}
+
+// Authorizer decides whether a request satisfies the requirements of an
+// endpoint. Returning a *Error controls the response, any other error is
+// answered with 403.
+type Authorizer interface {
+	Authorize(r *http.Request, requirements Requirements) error
+}
+
+type AuthorizerFunc func(r *http.Request, requirements Requirements) error
+
+func (f AuthorizerFunc) Authorize(r *http.Request, requirements Requirements) error {
+	return f(r, requirements)
+}
+
+// PrincipalAuthorizer is the default Authorizer, it requires the Principal on
+// the request to hold every required scope and role.
+var PrincipalAuthorizer Authorizer = AuthorizerFunc(func(r *http.Request, requirements Requirements) error {
+	principal := PrincipalFrom(r)
+	if principal == nil {
+		return NewError(http.StatusUnauthorized, "unauthenticated", fmt.Errorf("authentication required"))
+	}
+	if scope, ok := missing(principal.Scopes(), requirements.Scopes); !ok {
+		return NewError(http.StatusForbidden, "forbidden", fmt.Errorf("missing required scope %s", scope))
+	}
+	if role, ok := missing(principal.Roles(), requirements.Roles); !ok {
+		return NewError(http.StatusForbidden, "forbidden", fmt.Errorf("missing required role %s", role))
+	}
+	return nil
+})
+
+func missing(have, want []string) (string, bool) {
+	set := map[string]bool{}
+	for _, v := range have {
+		set[v] = true
+	}
+	for _, v := range want {
+		if !set[v] {
+			return v, false
+		}
+	}
+	return "", true
+}
+
+func (e *Endpoint) RequireScopes(scopes ...string) *Endpoint {
+	if e.Authorization == nil {
+		e.Authorization = &Requirements{}
+	}
+	e.Authorization.Scopes = append(e.Authorization.Scopes, scopes...)
+	return e
+}
+
+func (e *Endpoint) RequireRoles(roles ...string) *Endpoint {
+	if e.Authorization == nil {
+		e.Authorization = &Requirements{}
+	}
+	e.Authorization.Roles = append(e.Authorization.Roles, roles...)
+	return e
+}
+
+func (e *Endpoint) authorize(r *http.Request) error {
+	if e.Authorization == nil {
+		return nil
+	}
+	err := e.api.authorizer().Authorize(r, *e.Authorization)
+	if err == nil {
+		return nil
+	}
+	var fe *Error
+	if !errors.As(err, &fe) {
+		err = NewError(http.StatusForbidden, "forbidden", err)
+	}
+	return err
+}
+
+func (api *API) authorizer() Authorizer {
+	for a := api; a != nil; a = a.parent {
+		if a.Authorizer != nil {
+			return a.Authorizer
+		}
+	}
+	return PrincipalAuthorizer
+}
@@ -0,0 +1,134 @@
+package faust_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/nokusukun/faust"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func send(api *faust.API, method, path string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, path, nil)
+	for name, value := range header {
+		r.Header.Set(name, value)
+	}
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, r)
+	return w
+}
+
+// principal holds the comma separated X-Scopes and X-Roles of a request.
+type principal struct{ scopes, roles []string }
+
+func (p principal) Scopes() []string { return p.scopes }
+func (p principal) Roles() []string  { return p.roles }
+
+func authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if scopes, ok := r.Header["X-Scopes"]; ok {
+			roles := strings.Split(r.Header.Get("X-Roles"), ",")
+			r = faust.WithPrincipal(r, principal{scopes: strings.Split(scopes[0], ","), roles: roles})
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func errorType(t *testing.T, w *httptest.ResponseRecorder) string {
+	t.Helper()
+	var body struct{ Type string }
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("error body %q: %v", w.Body, err)
+	}
+	return body.Type
+}
+
+func TestRequireScopesAndRoles(t *testing.T) {
+	api := faust.New()
+	api.Mux.Use(authenticate)
+	ok := func(e *faust.Endpoint) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {}
+	}
+	api.Get("/public", ok)
+	api.Get("/items", func(e *faust.Endpoint) http.HandlerFunc {
+		e.RequireScopes("items:read", "items:list")
+		return ok(e)
+	})
+	api.Get("/admin", func(e *faust.Endpoint) http.HandlerFunc {
+		e.RequireScopes("items:read").RequireRoles("admin")
+		return ok(e)
+	})
+
+	tests := []struct {
+		path, scopes, roles string
+		status              int
+		errType             string
+	}{
+		{"/public", "", "", http.StatusOK, ""},
+		{"/items", "", "", http.StatusUnauthorized, "unauthenticated"},
+		{"/items", "items:read", "", http.StatusForbidden, "forbidden"},
+		{"/items", "items:list", "", http.StatusForbidden, "forbidden"},
+		{"/items", "items:read,items:list", "", http.StatusOK, ""},
+		{"/admin", "items:read", "user", http.StatusForbidden, "forbidden"},
+		{"/admin", "other", "admin", http.StatusForbidden, "forbidden"},
+		{"/admin", "items:read", "user,admin", http.StatusOK, ""},
+	}
+	for _, test := range tests {
+		header := map[string]string{}
+		if test.scopes != "" || test.roles != "" {
+			header["X-Scopes"] = test.scopes
+			header["X-Roles"] = test.roles
+		}
+		w := send(api, "GET", test.path, header)
+		if w.Code != test.status {
+			t.Errorf("%s with scopes %q and roles %q: got %d, want %d", test.path, test.scopes, test.roles, w.Code, test.status)
+			continue
+		}
+		if test.errType != "" && errorType(t, w) != test.errType {
+			t.Errorf("%s with scopes %q: got error type %q, want %q", test.path, test.scopes, errorType(t, w), test.errType)
+		}
+	}
+}
+
+func TestAuthorizerInheritance(t *testing.T) {
+	var checked []string
+	api := faust.New()
+	api.Authorizer = faust.AuthorizerFunc(func(r *http.Request, requirements faust.Requirements) error {
+		checked = append(checked, r.URL.Path)
+		switch r.Header.Get("X-Key") {
+		case "":
+			return faust.NewError(http.StatusUnauthorized, "unauthenticated", errors.New("no key"))
+		case "valid":
+			return nil
+		}
+		return errors.New("wrong key")
+	})
+	handler := func(e *faust.Endpoint) http.HandlerFunc {
+		e.RequireRoles("reader")
+		return func(w http.ResponseWriter, r *http.Request) {}
+	}
+	api.Subrouter("/v1").Subrouter("/admin").Get("/items", handler)
+	own := api.Subrouter("/v2")
+	own.Authorizer = faust.AuthorizerFunc(func(r *http.Request, requirements faust.Requirements) error {
+		return nil
+	})
+	own.Get("/items", handler)
+
+	if w := send(api, "GET", "/v1/admin/items", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("without a key: got %d, want 401", w.Code)
+	}
+	if w := send(api, "GET", "/v1/admin/items", map[string]string{"X-Key": "other"}); w.Code != http.StatusForbidden || errorType(t, w) != "forbidden" {
+		t.Errorf("plain errors: got %d %s, want a 403", w.Code, w.Body)
+	}
+	if w := send(api, "GET", "/v1/admin/items", map[string]string{"X-Key": "valid"}); w.Code != http.StatusOK {
+		t.Errorf("with a key: got %d, want 200", w.Code)
+	}
+	if w := send(api, "GET", "/v2/items", nil); w.Code != http.StatusOK {
+		t.Errorf("subrouter authorizer: got %d, want 200", w.Code)
+	}
+	if len(checked) != 3 {
+		t.Errorf("the root authorizer checked %q, want the three /v1 requests", checked)
+	}
+}
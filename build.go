@@ -0,0 +1,110 @@
+package faust
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nokusukun/faust/internal/pathvars"
+)
+
+func (api *API) root() *API {
+	for api.parent != nil {
+		api = api.parent
+	}
+	return api
+}
+
+// Build checks the declared endpoints for mistakes, registers the
+// documentation routes and freezes the router, adding routes afterwards
+// panics. It runs once, ServeHTTP calls it on the first request.
+func (api *API) Build() error {
+	root := api.root()
+	root.build.Do(func() {
+		root.buildErr = root.lint()
+		root.registerDocs()
+		root.registerPreflights()
+		root.built.Store(true)
+	})
+	return root.buildErr
+}
+
+func (api *API) checkNotBuilt() {
+	if api.root().built.Load() {
+		panic("faust: cannot add routes to an API after Build")
+	}
+}
+
+// lint reports path parameters missing from their path template, path
+// variables without a parameter, duplicate routes and names, endpoints with
+// several JSON bodies and queues without a concurrency limit.
+func (api *API) lint() error {
+	var errs []error
+	routes := map[string]bool{}
+	names := map[string]string{}
+	api.walk(func(e *Endpoint) {
+		path := e.api.prefix() + e.Path
+		route := e.Method + " " + path
+		fail := func(format string, args ...any) {
+			errs = append(errs, fmt.Errorf("%s: %s", route, fmt.Sprintf(format, args...)))
+		}
+
+		variables := map[string]bool{}
+		for _, name := range pathvars.Names(path) {
+			variables[name] = true
+		}
+		declared := map[string]bool{}
+		jsonBodies := 0
+		for _, param := range e.Params {
+			describer, ok := param.(ParamDescriber)
+			if !ok {
+				continue
+			}
+			in, name, _ := describer.ParamInfo()
+			switch in {
+			case "path":
+				declared[name] = true
+				if !variables[name] {
+					fail("path parameter %q is not in the path template", name)
+				}
+			case "jsonbody":
+				jsonBodies++
+			}
+		}
+		for _, name := range pathvars.Names(path) {
+			if !declared[name] {
+				fail("path variable %q has no path parameter", name)
+			}
+		}
+		if jsonBodies > 1 {
+			fail("%d JSON bodies declared, an endpoint can only read one", jsonBodies)
+		}
+		if e.concurrency != nil && e.concurrency.slots == nil {
+			fail("Queue is set without MaxInFlight")
+		}
+
+		// routes differing only in variable names still collide
+		key := e.Method + " " + pathvars.Unnamed(path)
+		if routes[key] {
+			fail("route is declared more than once")
+		}
+		routes[key] = true
+		if e.EndpointInfo.Name != "" {
+			if other, ok := names[e.EndpointInfo.Name]; ok {
+				fail("name %q is already used by %s", e.EndpointInfo.Name, other)
+			} else {
+				names[e.EndpointInfo.Name] = route
+			}
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// walk calls fn with every endpoint of the API and its subrouters, in the
+// order they were declared.
+func (api *API) walk(fn func(e *Endpoint)) {
+	for _, endpoint := range api.Endpoints {
+		fn(endpoint)
+	}
+	for _, sub := range api.Subrouters {
+		sub.walk(fn)
+	}
+}
@@ -0,0 +1,96 @@
+package faust_test
+
+import (
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/param"
+	"net/http"
+	"testing"
+)
+
+func noop(e *faust.Endpoint) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {}
+}
+
+func TestBuildLint(t *testing.T) {
+	type payload struct{ Name string }
+	tests := []struct {
+		name    string
+		declare func(api *faust.API)
+		want    string
+	}{
+		{"path parameter not in the template", func(api *faust.API) {
+			api.Get("/users", func(e *faust.Endpoint) http.HandlerFunc {
+				param.Path[int](e, "id")
+				return noop(e)
+			})
+		}, `GET /users: path parameter "id" is not in the path template`},
+		{"path variable without a parameter", func(api *faust.API) {
+			api.Subrouter("/orgs/{org}").Get("/codes/{code:[a-z]{3}}", func(e *faust.Endpoint) http.HandlerFunc {
+				param.Path[string](e, "code")
+				return noop(e)
+			})
+		}, `GET /orgs/{org}/codes/{code:[a-z]{3}}: path variable "org" has no path parameter`},
+		{"several JSON bodies", func(api *faust.API) {
+			api.Post("/users", func(e *faust.Endpoint) http.HandlerFunc {
+				param.Json[payload](e, "user")
+				param.Json[payload](e, "profile")
+				return noop(e)
+			})
+		}, `POST /users: 2 JSON bodies declared, an endpoint can only read one`},
+		{"queue without a concurrency limit", func(api *faust.API) {
+			api.Get("/reports", func(e *faust.Endpoint) http.HandlerFunc {
+				e.Queue(10, 0)
+				return noop(e)
+			})
+		}, `GET /reports: Queue is set without MaxInFlight`},
+		{"duplicate route", func(api *faust.API) {
+			api.Get("/users/{id:[0-9]+}", func(e *faust.Endpoint) http.HandlerFunc {
+				param.Path[int](e, "id")
+				return noop(e)
+			})
+			// only the variable name differs
+			api.Get("/users/{user:[0-9]+}", func(e *faust.Endpoint) http.HandlerFunc {
+				param.Path[int](e, "user")
+				return noop(e)
+			})
+		}, `GET /users/{user:[0-9]+}: route is declared more than once`},
+		{"duplicate name", func(api *faust.API) {
+			api.Get("/users", func(e *faust.Endpoint) http.HandlerFunc {
+				e.Name("list")
+				return noop(e)
+			})
+			api.Subrouter("/v2").Get("/users", func(e *faust.Endpoint) http.HandlerFunc {
+				e.Name("list")
+				return noop(e)
+			})
+		}, `GET /v2/users: name "list" is already used by GET /users`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			api := faust.New()
+			test.declare(api)
+			err := api.Build()
+			if err == nil || err.Error() != test.want {
+				t.Errorf("got %v, want %s", err, test.want)
+			}
+			if w := send(api, "GET", "/users", nil); w.Code != http.StatusInternalServerError {
+				t.Errorf("an API that failed to build served status %d", w.Code)
+			}
+		})
+	}
+}
+
+func TestBuildFreezesRoutes(t *testing.T) {
+	api := faust.New()
+	sub := api.Subrouter("/v1")
+	sub.Get("/users", noop)
+	if err := sub.Build(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("a route was added after Build")
+		}
+	}()
+	api.Get("/late", noop)
+}
@@ -0,0 +1,104 @@
+// Command faust-gen generates code from OpenAPI documents, e.g. a client
+// from the one a faust API serves at /openapi.json or a server from a spec
+// written first:
+//
+//	faust-gen ts -spec openapi.json -o client.ts
+//	faust-gen server -spec api.yaml -pkg api -o api/endpoints.go
+package main
+
+import (
+	"flag"
+	"fmt"
+	"github.com/nokusukun/faust/gen"
+	"github.com/nokusukun/faust/openapi"
+	"io"
+	"os"
+)
+
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"ts":     {"generate a TypeScript client", typescript},
+	"server": {"generate faust endpoints with handler stubs", server},
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]].run == nil {
+		fmt.Fprintln(os.Stderr, "usage: faust-gen <command> [flags]\n\ncommands:")
+		for name, cmd := range commands {
+			fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, cmd.usage)
+		}
+		os.Exit(2)
+	}
+	if err := commands[os.Args[1]].run(os.Args[2:]); err != nil {
+		fmt.Fprintln(os.Stderr, "faust-gen:", err)
+		os.Exit(1)
+	}
+}
+
+func typescript(args []string) error {
+	flags := flag.NewFlagSet("ts", flag.ExitOnError)
+	spec := flags.String("spec", "openapi.json", "OpenAPI document to read, JSON or YAML, - for stdin")
+	out := flags.String("o", "-", "file to write, - for stdout")
+	flags.Parse(args)
+
+	doc, err := readSpec(*spec)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, func(w io.Writer) error {
+		return gen.TypeScript(w, doc)
+	})
+}
+
+func server(args []string) error {
+	flags := flag.NewFlagSet("server", flag.ExitOnError)
+	spec := flags.String("spec", "openapi.json", "OpenAPI document to read, JSON or YAML, - for stdin")
+	pkg := flags.String("pkg", "api", "package of the generated code")
+	out := flags.String("o", "-", "file to write, - for stdout")
+	flags.Parse(args)
+
+	doc, err := readSpec(*spec)
+	if err != nil {
+		return err
+	}
+	return writeOutput(*out, func(w io.Writer) error {
+		return gen.Server(w, doc, *pkg)
+	})
+}
+
+func readSpec(path string) (*openapi.Document, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return nil, err
+	}
+	doc, err := openapi.Parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return doc, nil
+}
+
+func writeOutput(path string, write func(w io.Writer) error) error {
+	if path == "-" {
+		return write(os.Stdout)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := write(f); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
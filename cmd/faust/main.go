@@ -0,0 +1,98 @@
+// Command faust works with the documentation of faust APIs, e.g. to gate
+// releases on compatibility with the docs.json of the last one:
+//
+//	faust diff old.json new.json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/nokusukun/faust/apidiff"
+	"github.com/nokusukun/faust/docgen"
+	"io"
+	"os"
+)
+
+type command struct {
+	usage string
+	run   func(args []string, stdout io.Writer) (int, error)
+}
+
+var commands = map[string]command{
+	"diff": {"list the changes between two docs.json files, exit 1 on breaking ones", diff},
+}
+
+func main() {
+	if len(os.Args) < 2 || commands[os.Args[1]].run == nil {
+		fmt.Fprintln(os.Stderr, "usage: faust <command> [flags]\n\ncommands:")
+		for name, cmd := range commands {
+			fmt.Fprintf(os.Stderr, "  %-8s %s\n", name, cmd.usage)
+		}
+		os.Exit(2)
+	}
+	code, err := commands[os.Args[1]].run(os.Args[2:], os.Stdout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "faust:", err)
+		os.Exit(2)
+	}
+	os.Exit(code)
+}
+
+func diff(args []string, stdout io.Writer) (int, error) {
+	flags := flag.NewFlagSet("diff", flag.ExitOnError)
+	asJSON := flags.Bool("json", false, "write the changes as JSON")
+	breakingOnly := flags.Bool("breaking", false, "only list breaking changes")
+	flags.Usage = func() {
+		fmt.Fprintln(os.Stderr, "usage: faust diff [flags] old.json new.json")
+		flags.PrintDefaults()
+	}
+	flags.Parse(args)
+	if flags.NArg() != 2 {
+		flags.Usage()
+		return 2, nil
+	}
+
+	old, err := readDoc(flags.Arg(0))
+	if err != nil {
+		return 0, err
+	}
+	current, err := readDoc(flags.Arg(1))
+	if err != nil {
+		return 0, err
+	}
+	changes := []apidiff.Change{}
+	for _, change := range apidiff.Compare(old, current) {
+		if change.Breaking || !*breakingOnly {
+			changes = append(changes, change)
+		}
+	}
+
+	if *asJSON {
+		encoder := json.NewEncoder(stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(changes); err != nil {
+			return 0, err
+		}
+	} else {
+		for _, change := range changes {
+			fmt.Fprintln(stdout, change)
+		}
+	}
+	if apidiff.HasBreaking(changes) {
+		return 1, nil
+	}
+	return 0, nil
+}
+
+func readDoc(path string) (docgen.APIDoc, error) {
+	var doc docgen.APIDoc
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return doc, err
+	}
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return doc, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	return doc, nil
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/nokusukun/faust/apidiff"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func writeDocs(t *testing.T) (old, current string) {
+	t.Helper()
+	dir := t.TempDir()
+	old, current = filepath.Join(dir, "old.json"), filepath.Join(dir, "new.json")
+	docs := map[string]string{
+		old: `{"path": "/", "endpoints": [
+			{"method": "GET", "path": "/pets"},
+			{"method": "DELETE", "path": "/pets/{id}"}
+		]}`,
+		current: `{"path": "/", "endpoints": [
+			{"method": "GET", "path": "/pets", "parameters": [{"in": "query", "name": "sort", "optional": true, "schema": {"type": "string"}}]}
+		]}`,
+	}
+	for path, doc := range docs {
+		if err := os.WriteFile(path, []byte(doc), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return old, current
+}
+
+func TestDiff(t *testing.T) {
+	old, current := writeDocs(t)
+	tests := []struct {
+		args []string
+		code int
+		want string
+	}{
+		{[]string{old, current}, 1, "BREAKING DELETE /pets/{id}: endpoint removed\nnon-breaking GET /pets: query parameter sort: optional parameter added\n"},
+		{[]string{"-breaking", old, current}, 1, "BREAKING DELETE /pets/{id}: endpoint removed\n"},
+		{[]string{current, current}, 0, ""},
+		{[]string{old}, 2, ""},
+	}
+	for _, test := range tests {
+		var stdout bytes.Buffer
+		code, err := diff(test.args, &stdout)
+		if err != nil || code != test.code || stdout.String() != test.want {
+			t.Errorf("faust diff %s: got exit code %d, %v and output\n%s\nwant exit code %d and output\n%s", strings.Join(test.args, " "), code, err, &stdout, test.code, test.want)
+		}
+	}
+}
+
+func TestDiffJSON(t *testing.T) {
+	old, current := writeDocs(t)
+	var stdout bytes.Buffer
+	if _, err := diff([]string{"-json", "-breaking", old, current}, &stdout); err != nil {
+		t.Fatal(err)
+	}
+	var changes []apidiff.Change
+	if err := json.Unmarshal(stdout.Bytes(), &changes); err != nil {
+		t.Fatalf("%v: %s", err, &stdout)
+	}
+	want := apidiff.Change{Breaking: true, Endpoint: "DELETE /pets/{id}", Message: "endpoint removed"}
+	if len(changes) != 1 || changes[0] != want {
+		t.Errorf("got %+v", changes)
+	}
+
+	stdout.Reset()
+	diff([]string{"-json", current, current}, &stdout)
+	if got := strings.TrimSpace(stdout.String()); got != "[]" {
+		t.Errorf("got %s for no changes, want []", got)
+	}
+}
+
+func TestDiffErrors(t *testing.T) {
+	old, _ := writeDocs(t)
+	invalid := filepath.Join(t.TempDir(), "invalid.json")
+	os.WriteFile(invalid, []byte("{"), 0o644)
+	for _, args := range [][]string{{old, "missing.json"}, {old, invalid}} {
+		if _, err := diff(args, &bytes.Buffer{}); err == nil {
+			t.Errorf("faust diff %s: got no error", strings.Join(args, " "))
+		}
+	}
+}
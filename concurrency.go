@@ -0,0 +1,146 @@
+package faust
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+)
+
+type concurrencyLimit struct {
+	maxInFlight  int
+	queueSize    int
+	queueTimeout time.Duration
+	slots        chan struct{}
+}
+
+func (c *concurrencyLimit) MarshalJSON() ([]byte, error) {
+	info := map[string]any{
+		"max_in_flight": c.maxInFlight,
+	}
+	if c.queueSize > 0 {
+		info["queue"] = c.queueSize
+		info["queue_timeout"] = c.queueTimeout.String()
+	}
+	return json.Marshal(info)
+}
+
+// MaxInFlight bounds the number of requests the endpoint handles at the same
+// time, excess requests are rejected with 503 unless they can be queued. It
+// panics if n is not positive.
+func (e *Endpoint) MaxInFlight(n int) *Endpoint {
+	if n <= 0 {
+		panic(fmt.Sprintf("faust: MaxInFlight(%d) must allow at least one request", n))
+	}
+	if e.concurrency == nil {
+		e.concurrency = &concurrencyLimit{}
+	}
+	e.concurrency.maxInFlight = n
+	e.concurrency.slots = make(chan struct{}, n)
+	return e
+}
+
+// Queue lets up to n requests wait for at most timeout when the endpoint is
+// at its MaxInFlight limit, Build fails if MaxInFlight is not set.
+func (e *Endpoint) Queue(n int, timeout time.Duration) *Endpoint {
+	if e.concurrency == nil {
+		e.concurrency = &concurrencyLimit{}
+	}
+	e.concurrency.queueSize = n
+	e.concurrency.queueTimeout = timeout
+	return e
+}
+
+// EndpointLoad is a snapshot of the requests an endpoint is working on.
+type EndpointLoad struct {
+	Method      string `json:"method"`
+	Path        string `json:"path"`
+	Name        string `json:"name,omitempty"`
+	InFlight    int64  `json:"in_flight"`
+	Queued      int64  `json:"queued"`
+	MaxInFlight int    `json:"max_in_flight,omitempty"`
+	QueueSize   int    `json:"queue,omitempty"`
+}
+
+func (e *Endpoint) Load() EndpointLoad {
+	load := EndpointLoad{
+		Method:   e.Method,
+		Path:     e.api.prefix() + e.Path,
+		Name:     e.EndpointInfo.Name,
+		InFlight: atomic.LoadInt64(&e.inFlight),
+		Queued:   atomic.LoadInt64(&e.queued),
+	}
+	if e.concurrency != nil {
+		load.MaxInFlight = e.concurrency.maxInFlight
+		load.QueueSize = e.concurrency.queueSize
+	}
+	return load
+}
+
+// Load returns the load of every endpoint of the API and its subrouters.
+func (api *API) Load() []EndpointLoad {
+	var loads []EndpointLoad
+	for _, endpoint := range api.Endpoints {
+		loads = append(loads, endpoint.Load())
+	}
+	for _, sub := range api.Subrouters {
+		loads = append(loads, sub.Load()...)
+	}
+	return loads
+}
+
+// acquire waits for an execution slot of the endpoint, the returned release
+// function must be called once the request is done.
+func (e *Endpoint) acquire(r *http.Request) (func(), error) {
+	release := func() {
+		atomic.AddInt64(&e.inFlight, -1)
+	}
+	c := e.concurrency
+	if c == nil || c.slots == nil {
+		atomic.AddInt64(&e.inFlight, 1)
+		return release, nil
+	}
+	releaseSlot := func() {
+		<-c.slots
+		release()
+	}
+
+	select {
+	case c.slots <- struct{}{}:
+		atomic.AddInt64(&e.inFlight, 1)
+		return releaseSlot, nil
+	default:
+	}
+
+	if c.queueSize <= 0 || atomic.AddInt64(&e.queued, 1) > int64(c.queueSize) {
+		if c.queueSize > 0 {
+			atomic.AddInt64(&e.queued, -1)
+		}
+		return nil, overloaded(c, fmt.Errorf("too many concurrent requests"))
+	}
+	defer atomic.AddInt64(&e.queued, -1)
+
+	timer := time.NewTimer(c.queueTimeout)
+	defer timer.Stop()
+	select {
+	case c.slots <- struct{}{}:
+		atomic.AddInt64(&e.inFlight, 1)
+		return releaseSlot, nil
+	case <-timer.C:
+		return nil, overloaded(c, fmt.Errorf("timed out waiting for a free slot"))
+	case <-r.Context().Done():
+		return nil, overloaded(c, r.Context().Err())
+	}
+}
+
+func overloaded(c *concurrencyLimit, err error) error {
+	fe := NewError(http.StatusServiceUnavailable, "overloaded", err)
+	retryAfter := seconds(c.queueTimeout)
+	if retryAfter < 1 {
+		retryAfter = 1
+	}
+	fe.Header = http.Header{"Retry-After": {strconv.Itoa(retryAfter)}}
+	return fe
+}
@@ -0,0 +1,107 @@
+package faust_test
+
+import (
+	"github.com/nokusukun/faust"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// blockingAPI serves /slow with a handler that waits for release, started
+// receives a value whenever a handler starts.
+func blockingAPI(configure func(e *faust.Endpoint)) (api *faust.API, started chan struct{}, release chan struct{}) {
+	api = faust.New()
+	started, release = make(chan struct{}, 100), make(chan struct{})
+	api.Get("/slow", func(e *faust.Endpoint) http.HandlerFunc {
+		configure(e)
+		return func(w http.ResponseWriter, r *http.Request) {
+			started <- struct{}{}
+			<-release
+		}
+	})
+	return api, started, release
+}
+
+func TestMaxInFlight(t *testing.T) {
+	api, started, release := blockingAPI(func(e *faust.Endpoint) {
+		e.MaxInFlight(2)
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if w := send(api, "GET", "/slow", nil); w.Code != http.StatusOK {
+				t.Errorf("got status %d, want 200", w.Code)
+			}
+		}()
+		<-started
+	}
+	if load := api.Load()[0]; load.InFlight != 2 {
+		t.Errorf("got %d in flight, want 2", load.InFlight)
+	}
+	w := send(api, "GET", "/slow", nil)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("Retry-After") == "" {
+		t.Errorf("over the limit: got status %d and headers %v, want a 503 with Retry-After", w.Code, w.Header())
+	}
+	close(release)
+	wg.Wait()
+	if load := api.Load()[0]; load.InFlight != 0 {
+		t.Errorf("got %d in flight after the requests, want 0", load.InFlight)
+	}
+}
+
+func TestQueue(t *testing.T) {
+	api, started, release := blockingAPI(func(e *faust.Endpoint) {
+		e.MaxInFlight(1).Queue(1, time.Second)
+	})
+	results := make(chan int, 2)
+	go func() { results <- send(api, "GET", "/slow", nil).Code }()
+	<-started
+	go func() { results <- send(api, "GET", "/slow", nil).Code }()
+	for api.Load()[0].Queued != 1 {
+		time.Sleep(time.Millisecond)
+	}
+	if w := send(api, "GET", "/slow", nil); w.Code != http.StatusServiceUnavailable {
+		t.Errorf("with a full queue: got status %d, want 503", w.Code)
+	}
+	close(release)
+	for i := 0; i < 2; i++ {
+		if code := <-results; code != http.StatusOK {
+			t.Errorf("got status %d, want 200", code)
+		}
+	}
+}
+
+func TestQueueTimeout(t *testing.T) {
+	api, started, release := blockingAPI(func(e *faust.Endpoint) {
+		e.MaxInFlight(1).Queue(5, 20*time.Millisecond)
+	})
+	defer close(release)
+	go send(api, "GET", "/slow", nil)
+	<-started
+	w := send(api, "GET", "/slow", nil)
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), "timed out waiting") {
+		t.Errorf("got status %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestConcurrencyMisconfiguration(t *testing.T) {
+	for _, n := range []int{0, -1} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("MaxInFlight(%d) did not panic", n)
+				}
+			}()
+			blockingAPI(func(e *faust.Endpoint) { e.MaxInFlight(n) })
+		}()
+	}
+
+	api, _, _ := blockingAPI(func(e *faust.Endpoint) { e.Queue(10, time.Second) })
+	if err := api.Build(); err == nil || !strings.Contains(err.Error(), "Queue is set without MaxInFlight") {
+		t.Errorf("Queue without MaxInFlight: got Build error %v", err)
+	}
+}
@@ -0,0 +1,186 @@
+package faust
+
+import (
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type CORSConfig struct {
+	// AllowOrigins lists the allowed origins, "*" allows any origin and a "*"
+	// inside an origin matches any run of characters, e.g.
+	// "https://*.example.com".
+	AllowOrigins []string
+	// AllowOriginPatterns are matched against the origin in addition to
+	// AllowOrigins.
+	AllowOriginPatterns []*regexp.Regexp
+	// AllowMethods defaults to the methods registered for the requested path.
+	AllowMethods []string
+	// AllowHeaders defaults to the headers requested by the preflight.
+	AllowHeaders  []string
+	ExposeHeaders []string
+	// AllowCredentials lets browsers send cookies and credentials, it can't
+	// be combined with the "*" origin.
+	AllowCredentials bool
+	MaxAge           time.Duration
+}
+
+// CORS enables cross-origin requests for every endpoint of the API and its
+// subrouters, unless a subrouter sets its own configuration. Preflight
+// requests are answered for every registered path. It panics when called
+// after Build, which registers the preflight routes, or when credentials are
+// allowed from any origin, which lets every site read responses made with
+// the user's credentials.
+func (api *API) CORS(config CORSConfig) *API {
+	if api.root().built.Load() {
+		panic("faust: cannot configure CORS after Build")
+	}
+	if config.AllowCredentials && containsFold(config.AllowOrigins, "*") {
+		panic(`faust: CORS cannot allow credentials from the "*" origin, list the allowed origins instead`)
+	}
+	api.cors = &config
+	return api
+}
+
+func (api *API) corsConfig() *CORSConfig {
+	for a := api; a != nil; a = a.parent {
+		if a.cors != nil {
+			return a.cors
+		}
+	}
+	return nil
+}
+
+func (c *CORSConfig) allowOrigin(origin string) bool {
+	for _, allowed := range c.AllowOrigins {
+		if allowed == "*" || allowed == origin {
+			return true
+		}
+		if strings.Contains(allowed, "*") && matchWildcard(allowed, origin) {
+			return true
+		}
+	}
+	for _, pattern := range c.AllowOriginPatterns {
+		if pattern.MatchString(origin) {
+			return true
+		}
+	}
+	return false
+}
+
+func matchWildcard(pattern, s string) bool {
+	parts := strings.Split(pattern, "*")
+	if !strings.HasPrefix(s, parts[0]) {
+		return false
+	}
+	s = s[len(parts[0]):]
+	for i, part := range parts[1:] {
+		if i == len(parts)-2 {
+			return strings.HasSuffix(s, part)
+		}
+		idx := strings.Index(s, part)
+		if idx < 0 {
+			return false
+		}
+		s = s[idx+len(part):]
+	}
+	return true
+}
+
+// writeOrigin sets the origin headers shared by preflight and actual
+// requests, it reports whether the origin is allowed.
+func (c *CORSConfig) writeOrigin(w http.ResponseWriter, origin string) bool {
+	w.Header().Add("Vary", "Origin")
+	if !c.allowOrigin(origin) {
+		return false
+	}
+	if len(c.AllowOrigins) == 1 && c.AllowOrigins[0] == "*" {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+	} else {
+		w.Header().Set("Access-Control-Allow-Origin", origin)
+	}
+	if c.AllowCredentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+	return true
+}
+
+func (c *CORSConfig) handleActual(w http.ResponseWriter, r *http.Request) {
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		return
+	}
+	if c.writeOrigin(w, origin) && len(c.ExposeHeaders) > 0 {
+		w.Header().Set("Access-Control-Expose-Headers", strings.Join(c.ExposeHeaders, ", "))
+	}
+}
+
+func (c *CORSConfig) preflight(methods []string) http.HandlerFunc {
+	allowMethods := methods
+	if len(c.AllowMethods) > 0 {
+		allowMethods = c.AllowMethods
+	}
+	allow := strings.Join(methods, ", ") + ", OPTIONS"
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Allow", allow)
+		origin := r.Header.Get("Origin")
+		requestMethod := r.Header.Get("Access-Control-Request-Method")
+		if origin == "" || requestMethod == "" {
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		w.Header().Add("Vary", "Access-Control-Request-Method")
+		w.Header().Add("Vary", "Access-Control-Request-Headers")
+		if !c.writeOrigin(w, origin) || !containsFold(allowMethods, requestMethod) {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		w.Header().Set("Access-Control-Allow-Methods", strings.Join(allowMethods, ", "))
+		if len(c.AllowHeaders) > 0 {
+			w.Header().Set("Access-Control-Allow-Headers", strings.Join(c.AllowHeaders, ", "))
+		} else if requested := r.Header.Get("Access-Control-Request-Headers"); requested != "" {
+			w.Header().Set("Access-Control-Allow-Headers", requested)
+		}
+		if c.MaxAge > 0 {
+			w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(c.MaxAge.Seconds())))
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// registerPreflights adds an OPTIONS route for every path of the API and its
+// subrouters that has CORS enabled and no OPTIONS endpoint of its own.
+func (api *API) registerPreflights() {
+	if cors := api.corsConfig(); cors != nil {
+		var paths []string
+		methods := map[string][]string{}
+		for _, endpoint := range api.Endpoints {
+			if _, ok := methods[endpoint.Path]; !ok {
+				paths = append(paths, endpoint.Path)
+			}
+			methods[endpoint.Path] = append(methods[endpoint.Path], endpoint.Method)
+		}
+		for _, path := range paths {
+			if containsFold(methods[path], "OPTIONS") {
+				continue
+			}
+			sort.Strings(methods[path])
+			api.Mux.HandleFunc(path, cors.preflight(methods[path])).Methods("OPTIONS")
+		}
+	}
+	for _, sub := range api.Subrouters {
+		sub.registerPreflights()
+	}
+}
+
+func containsFold(values []string, value string) bool {
+	for _, v := range values {
+		if strings.EqualFold(v, value) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,117 @@
+package faust_test
+
+import (
+	"github.com/nokusukun/faust"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func corsAPI(config faust.CORSConfig) *faust.API {
+	api := faust.New()
+	handler := func(e *faust.Endpoint) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {}
+	}
+	api.Get("/items", handler)
+	api.Post("/items", handler)
+	api.Subrouter("/admin").CORS(faust.CORSConfig{AllowOrigins: []string{"https://admin.example.com"}}).
+		Delete("/items", handler)
+	return api.CORS(config)
+}
+
+func TestCORSPreflight(t *testing.T) {
+	api := corsAPI(faust.CORSConfig{
+		AllowOrigins:     []string{"https://app.example.com", "https://*.example.dev"},
+		AllowCredentials: true,
+		MaxAge:           time.Hour,
+	})
+	tests := []struct {
+		name    string
+		path    string
+		origin  string
+		method  string
+		status  int
+		headers map[string]string
+	}{
+		{"allowed origin", "/items", "https://app.example.com", "POST", http.StatusNoContent, map[string]string{
+			"Access-Control-Allow-Origin":      "https://app.example.com",
+			"Access-Control-Allow-Methods":     "GET, POST",
+			"Access-Control-Allow-Credentials": "true",
+			"Access-Control-Allow-Headers":     "Content-Type",
+			"Access-Control-Max-Age":           "3600",
+		}},
+		{"wildcard origin", "/items", "https://pr-1.example.dev", "GET", http.StatusNoContent, map[string]string{
+			"Access-Control-Allow-Origin": "https://pr-1.example.dev",
+		}},
+		{"other origin", "/items", "https://evil.example", "GET", http.StatusForbidden, map[string]string{
+			"Access-Control-Allow-Origin":      "",
+			"Access-Control-Allow-Credentials": "",
+		}},
+		{"unregistered method", "/items", "https://app.example.com", "DELETE", http.StatusForbidden, nil},
+		{"subrouter configuration", "/admin/items", "https://admin.example.com", "DELETE", http.StatusNoContent, map[string]string{
+			"Access-Control-Allow-Origin":      "https://admin.example.com",
+			"Access-Control-Allow-Methods":     "DELETE",
+			"Access-Control-Allow-Credentials": "",
+		}},
+		{"parent origin on subrouter", "/admin/items", "https://app.example.com", "DELETE", http.StatusForbidden, nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := send(api, "OPTIONS", test.path, map[string]string{
+				"Origin":                         test.origin,
+				"Access-Control-Request-Method":  test.method,
+				"Access-Control-Request-Headers": "Content-Type",
+			})
+			if w.Code != test.status {
+				t.Errorf("got status %d, want %d", w.Code, test.status)
+			}
+			for name, want := range test.headers {
+				if got := w.Header().Get(name); got != want {
+					t.Errorf("%s: got %q, want %q", name, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestCORSActualRequest(t *testing.T) {
+	api := corsAPI(faust.CORSConfig{AllowOrigins: []string{"*"}, ExposeHeaders: []string{"X-Request-Id"}})
+	w := send(api, "GET", "/items", map[string]string{"Origin": "https://any.example"})
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "*" {
+		t.Errorf("Access-Control-Allow-Origin: got %q, want *", got)
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != "X-Request-Id" {
+		t.Errorf("Access-Control-Expose-Headers: got %q", got)
+	}
+
+	w = send(api, "GET", "/items", nil)
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("request without Origin: got Access-Control-Allow-Origin %q", got)
+	}
+}
+
+func TestCORSMisconfiguration(t *testing.T) {
+	mustPanic := func(name string, fn func()) {
+		t.Helper()
+		defer func() {
+			if recover() == nil {
+				t.Errorf("%s: did not panic", name)
+			}
+		}()
+		fn()
+	}
+	mustPanic("credentials from any origin", func() {
+		faust.New().CORS(faust.CORSConfig{AllowOrigins: []string{"*"}, AllowCredentials: true})
+	})
+	mustPanic("after Build", func() {
+		api := faust.New()
+		api.Build()
+		api.CORS(faust.CORSConfig{AllowOrigins: []string{"https://app.example.com"}})
+	})
+	mustPanic("subrouter after Build", func() {
+		api := faust.New()
+		sub := api.Subrouter("/v1")
+		api.Build()
+		sub.CORS(faust.CORSConfig{AllowOrigins: []string{"https://app.example.com"}})
+	})
+}
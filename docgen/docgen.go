@@ -6,42 +6,175 @@ import (
 	"fmt"
 	"html/template"
 	"os"
+	"regexp"
+	"sort"
+	"strings"
 )
 
 type Schema struct {
-	Type   string `json:"type"`
-	Format string `json:"format"`
+	Name                 string            `json:"name"`
+	Type                 string            `json:"type"`
+	Format               string            `json:"format"`
+	Properties           map[string]Schema `json:"properties"`
+	Required             []string          `json:"required"`
+	Items                *Schema           `json:"items"`
+	AdditionalProperties *Schema           `json:"additionalProperties"`
+	Enum                 []any             `json:"enum"`
 }
 
 type Parameter struct {
 	In          string `json:"in"`
 	Name        string `json:"name"`
 	Description string `json:"description"`
+	Optional    bool   `json:"optional"`
 	Schema      Schema `json:"schema"`
 }
 
+// Example is a JSON example of the parameter value, derived from its schema.
+func (p Parameter) Example() string {
+	example, _ := json.MarshalIndent(Example(p.Schema), "", "  ")
+	return string(example)
+}
+
+type Response struct {
+	Status      int     `json:"status"`
+	Description string  `json:"description"`
+	Schema      *Schema `json:"schema"`
+}
+
+type Authorization struct {
+	Scopes []string `json:"scopes"`
+	Roles  []string `json:"roles"`
+}
+
+type RateLimit struct {
+	Limit     int    `json:"limit"`
+	Window    string `json:"window"`
+	Algorithm string `json:"algorithm"`
+}
+
+type Concurrency struct {
+	MaxInFlight  int    `json:"max_in_flight"`
+	Queue        int    `json:"queue"`
+	QueueTimeout string `json:"queue_timeout"`
+}
+
 type Endpoint struct {
-	Method      string      `json:"method"`
-	Path        string      `json:"path"`
-	Description string      `json:"description"`
-	Parameters  []Parameter `json:"parameters"`
+	Method        string         `json:"method"`
+	Path          string         `json:"path"`
+	Name          string         `json:"name"`
+	Description   string         `json:"description"`
+	Parameters    []Parameter    `json:"parameters"`
+	Authorization *Authorization `json:"authorization"`
+	Responses     []Response     `json:"responses"`
+	RateLimit     *RateLimit     `json:"rate_limit"`
+	Concurrency   *Concurrency   `json:"concurrency"`
+	Timeout       string         `json:"timeout"`
+	Tags          []string       `json:"tags"`
+	// FullPath is the path of the endpoint including its subroutes.
+	FullPath string `json:"-"`
+	// Anchor is the id of the endpoint in the HTML documentation.
+	Anchor string `json:"-"`
 }
 
 type Subroute struct {
 	Path      string     `json:"path"`
 	Endpoints []Endpoint `json:"endpoints"`
+	Subroutes []Subroute `json:"subroutes"`
+	// FullPath is the path of the subroute including its parents.
+	FullPath string `json:"-"`
 }
 
 type APIDoc struct {
-	Title     string     `json:"title"`
-	Summary   string     `json:"summary"`
-	Version   string     `json:"version"`
-	Path      string     `json:"path"`
-	Subroutes []Subroute `json:"subroutes"`
-	Endpoints []Endpoint `json:"endpoints"`
+	Title       string     `json:"title"`
+	Summary     string     `json:"summary"`
+	Description string     `json:"description"`
+	Version     string     `json:"version"`
+	Path        string     `json:"path"`
+	Subroutes   []Subroute `json:"subroutes"`
+	Endpoints   []Endpoint `json:"endpoints"`
+}
+
+// Root returns the API as the root of its subroute tree, with the full paths
+// and anchors of every subroute and endpoint resolved.
+func (apiDoc APIDoc) Root() Subroute {
+	root := Subroute{
+		Path:      apiDoc.Path,
+		Endpoints: apiDoc.Endpoints,
+		Subroutes: apiDoc.Subroutes,
+	}
+	resolve(&root, "", map[string]int{})
+	return root
 }
 
-func GenerateHTML(apiDoc APIDoc) string {
+func resolve(subroute *Subroute, prefix string, anchors map[string]int) {
+	subroute.FullPath = joinPath(prefix, subroute.Path)
+	subroute.Endpoints = append([]Endpoint{}, subroute.Endpoints...)
+	for i := range subroute.Endpoints {
+		endpoint := &subroute.Endpoints[i]
+		endpoint.FullPath = joinPath(subroute.FullPath, endpoint.Path)
+		endpoint.Anchor = anchor(endpoint.Method+" "+endpoint.FullPath, anchors)
+	}
+	subroute.Subroutes = append([]Subroute{}, subroute.Subroutes...)
+	for i := range subroute.Subroutes {
+		resolve(&subroute.Subroutes[i], subroute.FullPath, anchors)
+	}
+}
+
+// AllEndpoints returns every endpoint of the subroute tree, depth first.
+func (s Subroute) AllEndpoints() []Endpoint {
+	endpoints := append([]Endpoint{}, s.Endpoints...)
+	for _, subroute := range s.Subroutes {
+		endpoints = append(endpoints, subroute.AllEndpoints()...)
+	}
+	return endpoints
+}
+
+type TagGroup struct {
+	Tag       string
+	Endpoints []Endpoint
+}
+
+// TagGroups groups the endpoints of the subroute tree by their tags, sorted
+// by tag.
+func (s Subroute) TagGroups() []TagGroup {
+	groups := map[string][]Endpoint{}
+	for _, endpoint := range s.AllEndpoints() {
+		for _, tag := range endpoint.Tags {
+			groups[tag] = append(groups[tag], endpoint)
+		}
+	}
+	tags := make([]string, 0, len(groups))
+	for tag := range groups {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+	result := make([]TagGroup, 0, len(tags))
+	for _, tag := range tags {
+		result = append(result, TagGroup{Tag: tag, Endpoints: groups[tag]})
+	}
+	return result
+}
+
+var nonAnchor = regexp.MustCompile(`[^a-z0-9]+`)
+
+// anchor slugifies s into a unique HTML id.
+func anchor(s string, anchors map[string]int) string {
+	id := strings.Trim(nonAnchor.ReplaceAllString(strings.ToLower(s), "-"), "-")
+	anchors[id]++
+	if n := anchors[id]; n > 1 {
+		id = fmt.Sprintf("%s-%d", id, n)
+	}
+	return id
+}
+
+type Options struct {
+	// Interactive adds a form to every endpoint that sends requests to the
+	// API from the browser.
+	Interactive bool
+}
+
+func GenerateHTML(apiDoc APIDoc, options ...Options) string {
 	if apiDoc.Title == "" {
 		apiDoc.Title = " Faust API"
 	}
@@ -51,15 +184,70 @@ func GenerateHTML(apiDoc APIDoc) string {
 	if apiDoc.Version == "" {
 		apiDoc.Version = "1.0.0"
 	}
+	root := apiDoc.Root()
+	data := struct {
+		APIDoc
+		Options
+		Root Subroute
+		Tags []TagGroup
+	}{APIDoc: apiDoc, Root: root, Tags: root.TagGroups()}
+	if len(options) > 0 {
+		data.Options = options[0]
+	}
 	t := template.Must(template.New("apiDoc").Parse(tmpl))
 	var result bytes.Buffer
-	err := template.Must(t.Clone()).Execute(&result, apiDoc)
+	err := template.Must(t.Clone()).Execute(&result, data)
 	if err != nil {
 		panic(err)
 	}
 	return result.String()
 }
 
+func joinPath(prefix, path string) string {
+	if prefix == "/" || prefix == "" {
+		return path
+	}
+	return prefix + path
+}
+
+// Example builds an example value of the schema, e.g. to prefill request
+// bodies.
+func Example(schema Schema) any {
+	if len(schema.Enum) > 0 {
+		return schema.Enum[0]
+	}
+	switch schema.Type {
+	case "struct":
+		example := map[string]any{}
+		for name, property := range schema.Properties {
+			example[name] = Example(property)
+		}
+		return example
+	case "slice", "array":
+		if schema.Items == nil {
+			return []any{}
+		}
+		return []any{Example(*schema.Items)}
+	case "map":
+		if schema.AdditionalProperties == nil {
+			return map[string]any{}
+		}
+		return map[string]any{"key": Example(*schema.AdditionalProperties)}
+	case "string":
+		if schema.Format == "date-time" {
+			return "2006-01-02T15:04:05Z"
+		}
+		return "string"
+	case "bool":
+		return false
+	case "int", "int8", "int16", "int32", "int64", "uint", "uint8", "uint16", "uint32", "uint64":
+		return 0
+	case "float32", "float64":
+		return 0.0
+	}
+	return nil
+}
+
 func main() {
 	jsonData := `{
 		"title": "Users API",
@@ -0,0 +1,291 @@
+package docgen
+
+import (
+	"fmt"
+	"github.com/nokusukun/faust/internal/pathvars"
+	"github.com/nokusukun/faust/openapi"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+const bearerScheme = "bearerAuth"
+
+// OpenAPI converts the documentation of an API into an OpenAPI 3 document,
+// e.g. for Swagger UI, ReDoc or client generators.
+func OpenAPI(apiDoc APIDoc) *openapi.Document {
+	doc := &openapi.Document{
+		OpenAPI: "3.0.3",
+		Info: openapi.Info{
+			Title:       apiDoc.Title,
+			Description: apiDoc.Summary,
+			Version:     apiDoc.Version,
+		},
+		Paths: map[string]*openapi.PathItem{},
+		Components: &openapi.Components{
+			Schemas: map[string]*openapi.Schema{
+				"Error": {
+					Type: "object",
+					Properties: map[string]*openapi.Schema{
+						"error":      {Type: "string"},
+						"type":       {Type: "string"},
+						"request_id": {Type: "string"},
+					},
+					Required: []string{"error", "type"},
+				},
+			},
+		},
+	}
+	if doc.Info.Title == "" {
+		doc.Info.Title = "Faust API"
+	}
+	if doc.Info.Version == "" {
+		doc.Info.Version = "1.0.0"
+	}
+	c := &converter{doc: doc, building: map[string]bool{}}
+	c.addOperations(apiDoc.Root(), true)
+	return doc
+}
+
+// addOperations adds the endpoints of the subroute tree, endpoints without
+// tags of their own are tagged with the path of their subroute.
+func (c *converter) addOperations(subroute Subroute, root bool) {
+	for _, endpoint := range subroute.Endpoints {
+		tags := endpoint.Tags
+		if len(tags) == 0 && !root {
+			tags = []string{strings.Trim(subroute.FullPath, "/")}
+		}
+		c.addOperation(endpoint, tags)
+	}
+	for _, child := range subroute.Subroutes {
+		c.addOperations(child, false)
+	}
+}
+
+func (c *converter) addOperation(endpoint Endpoint, tags []string) {
+	doc := c.doc
+	path := pathvars.Replace(endpoint.FullPath, func(v pathvars.Variable) string {
+		return "{" + v.Name + "}"
+	})
+	operation := &openapi.Operation{
+		OperationID: endpoint.Name,
+		Summary:     endpoint.Description,
+		Tags:        tags,
+		Responses: map[string]*openapi.Response{
+			"default": {
+				Description: "Error response",
+				Content: map[string]*openapi.MediaType{
+					"application/json": {Schema: &openapi.Schema{Ref: "#/components/schemas/Error"}},
+				},
+			},
+		},
+	}
+	if operation.OperationID == "" {
+		operation.OperationID = operationID(endpoint.Method, path)
+	}
+	for _, response := range endpoint.Responses {
+		description := response.Description
+		if description == "" {
+			description = http.StatusText(response.Status)
+		}
+		result := &openapi.Response{Description: description}
+		if response.Schema != nil {
+			result.Content = map[string]*openapi.MediaType{
+				"application/json": {Schema: c.schema(*response.Schema)},
+			}
+		}
+		operation.Responses[strconv.Itoa(response.Status)] = result
+	}
+	if len(endpoint.Responses) == 0 {
+		operation.Responses["200"] = &openapi.Response{Description: "Successful response"}
+	}
+	var scopes []string
+	secured := false
+	form := &openapi.Schema{Type: "object", Properties: map[string]*openapi.Schema{}}
+	for _, param := range endpoint.Parameters {
+		switch {
+		case param.Schema.Type == "jwt":
+			secured = true
+			continue
+		case param.In == "jsonbody":
+			operation.RequestBody = &openapi.RequestBody{
+				Description: param.Description,
+				Required:    !param.Optional,
+				Content: map[string]*openapi.MediaType{
+					"application/json": {Schema: c.schema(param.Schema)},
+				},
+			}
+		case param.In == "body":
+			operation.RequestBody = &openapi.RequestBody{
+				Description: param.Description,
+				Required:    !param.Optional,
+				Content: map[string]*openapi.MediaType{
+					"text/plain": {Schema: &openapi.Schema{Type: "string"}},
+				},
+			}
+		case param.In == "form":
+			schema := c.schema(param.Schema)
+			schema.Description = param.Description
+			form.Properties[param.Name] = schema
+			if !param.Optional {
+				form.Required = append(form.Required, param.Name)
+			}
+		default:
+			operation.Parameters = append(operation.Parameters, &openapi.Parameter{
+				Name:        param.Name,
+				In:          param.In,
+				Description: param.Description,
+				// path parameters are always required in OpenAPI
+				Required: !param.Optional || param.In == "path",
+				Schema:   c.schema(param.Schema),
+			})
+		}
+	}
+	if len(form.Properties) > 0 && operation.RequestBody == nil {
+		operation.RequestBody = &openapi.RequestBody{
+			Required: len(form.Required) > 0,
+			Content: map[string]*openapi.MediaType{
+				"application/x-www-form-urlencoded": {Schema: form},
+			},
+		}
+	}
+	if endpoint.Authorization != nil {
+		secured = true
+		scopes = append(scopes, endpoint.Authorization.Scopes...)
+	}
+	if secured {
+		if doc.Components.SecuritySchemes == nil {
+			doc.Components.SecuritySchemes = map[string]*openapi.SecurityScheme{}
+		}
+		doc.Components.SecuritySchemes[bearerScheme] = &openapi.SecurityScheme{
+			Type:         "http",
+			Scheme:       "bearer",
+			BearerFormat: "JWT",
+		}
+		if scopes == nil {
+			scopes = []string{}
+		}
+		operation.Security = []map[string][]string{{bearerScheme: scopes}}
+	}
+	item := doc.Paths[path]
+	if item == nil {
+		item = &openapi.PathItem{}
+		doc.Paths[path] = item
+	}
+	item.SetOperation(endpoint.Method, operation)
+}
+
+// operationID derives an identifier like getUsersId from the method and path.
+func operationID(method, path string) string {
+	id := strings.ToLower(method)
+	for _, part := range strings.FieldsFunc(path, func(r rune) bool {
+		return !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9')
+	}) {
+		id += strings.ToUpper(part[:1]) + part[1:]
+	}
+	return id
+}
+
+type converter struct {
+	doc *openapi.Document
+	// building are the named schemas being converted, references to them
+	// from recursive types are not expanded again
+	building map[string]bool
+}
+
+// schema converts a schema described with Go kinds into its OpenAPI
+// equivalent, named structs become component schemas.
+func (c *converter) schema(schema Schema) *openapi.Schema {
+	result := &openapi.Schema{Format: schema.Format}
+	switch schema.Type {
+	case "struct":
+		if schema.Name != "" {
+			return c.component(schema)
+		}
+		return c.object(schema)
+	case "slice", "array":
+		result.Type = "array"
+		result.Format = ""
+		result.Items = &openapi.Schema{}
+		if schema.Items != nil {
+			result.Items = c.schema(*schema.Items)
+		}
+	case "map":
+		result.Type = "object"
+		result.Format = ""
+		if schema.AdditionalProperties != nil {
+			result.AdditionalProperties = c.schema(*schema.AdditionalProperties)
+		}
+	case "string":
+		result.Type = "string"
+		if result.Format != "date-time" {
+			result.Format = ""
+		}
+	case "bool":
+		result.Type = "boolean"
+		result.Format = ""
+	case "int", "int64", "uint", "uint64":
+		result.Type = "integer"
+		result.Format = "int64"
+	case "int8", "int16", "int32", "uint8", "uint16", "uint32":
+		result.Type = "integer"
+		result.Format = "int32"
+	case "float32":
+		result.Type = "number"
+		result.Format = "float"
+	case "float64":
+		result.Type = "number"
+		result.Format = "double"
+	default:
+		result.Type = ""
+		result.Format = ""
+	}
+	if strings.HasPrefix(schema.Type, "uint") {
+		minimum := 0.0
+		result.Minimum = &minimum
+	}
+	result.Enum = schema.Enum
+	return result
+}
+
+func (c *converter) object(schema Schema) *openapi.Schema {
+	result := &openapi.Schema{
+		Type:       "object",
+		Required:   schema.Required,
+		Properties: map[string]*openapi.Schema{},
+	}
+	for name, property := range schema.Properties {
+		result.Properties[name] = c.schema(property)
+	}
+	return result
+}
+
+// component adds a named struct to the component schemas and references it,
+// structs of different packages sharing a name are numbered.
+func (c *converter) component(schema Schema) *openapi.Schema {
+	schemas := c.doc.Components.Schemas
+	name := schema.Name
+	for i := 2; ; i++ {
+		existing, ok := schemas[name]
+		if !ok {
+			break
+		}
+		// recursive references and the expanded type itself
+		if c.building[name] || schema.Properties == nil {
+			return &openapi.Schema{Ref: "#/components/schemas/" + name}
+		}
+		c.building[name] = true
+		object := c.object(schema)
+		delete(c.building, name)
+		if reflect.DeepEqual(existing, object) {
+			return &openapi.Schema{Ref: "#/components/schemas/" + name}
+		}
+		name = fmt.Sprintf("%s%d", schema.Name, i)
+	}
+	schemas[name] = &openapi.Schema{Type: "object"}
+	c.building[name] = true
+	schemas[name] = c.object(schema)
+	delete(c.building, name)
+	return &openapi.Schema{Ref: "#/components/schemas/" + name}
+}
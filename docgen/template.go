@@ -1,6 +1,88 @@
 package docgen
 
 const tmpl = `
+{{define "endpoint"}}
+<div class="endpoint" id="{{.Anchor}}">
+	<p class="method"><a href="#{{.Anchor}}">{{.Method}} {{.FullPath}}</a></p>
+	{{with .Name}}<p class="name">{{.}}</p>{{end}}
+	<p>{{.Description}}</p>
+	{{if .Tags}}<p class="tags">{{range .Tags}}<span class="tag">{{.}}</span>{{end}}</p>{{end}}
+	{{with .Authorization}}
+	<p class="requires"><strong>Requires:</strong>
+		{{if .Scopes}}scopes {{range .Scopes}}<code>{{.}}</code>{{end}}{{end}}
+		{{if .Roles}}roles {{range .Roles}}<code>{{.}}</code>{{end}}{{end}}
+	</p>
+	{{end}}
+	{{with .RateLimit}}
+	<p class="limits"><strong>Rate limit:</strong> {{.Limit}} requests per {{.Window}}</p>
+	{{end}}
+	{{with .Concurrency}}
+	<p class="limits"><strong>Concurrency:</strong> at most {{.MaxInFlight}} in flight{{if .Queue}}, {{.Queue}} queued for up to {{.QueueTimeout}}{{end}}</p>
+	{{end}}
+	{{with .Timeout}}
+	<p class="limits"><strong>Timeout:</strong> {{.}}</p>
+	{{end}}
+	{{if .Parameters}}
+	<p><strong>Parameters:</strong></p>
+	<ul class="parameters">
+		{{range .Parameters}}
+		<li><span class="param-name">{{.Name}}</span> (in {{.In}}) - {{.Description}} <span class="param-type">[{{.Schema.Type}}]</span>{{with .Schema.Enum}} one of {{range .}}<code>{{.}}</code>{{end}}{{end}}</li>
+		{{end}}
+	</ul>
+	{{end}}
+	{{if .Responses}}
+	<p><strong>Responses:</strong></p>
+	<ul class="parameters">
+		{{range .Responses}}
+		<li><span class="param-name">{{.Status}}</span> - {{.Description}}{{with .Schema}} <span class="param-type">[{{if .Name}}{{.Name}}{{else}}{{.Type}}{{end}}]</span>{{end}}</li>
+		{{end}}
+	</ul>
+	{{end}}
+	<details class="try">
+		<summary>Try it out</summary>
+		<form data-method="{{.Method}}" data-path="{{.FullPath}}" onsubmit="return tryIt(this)">
+			{{range .Parameters}}
+			{{if or (eq .In "jsonbody") (eq .In "body")}}
+			<label>{{.Name}} <span class="param-type">(body)</span>
+				<textarea rows="8" data-in="{{.In}}" data-name="{{.Name}}">{{if eq .In "jsonbody"}}{{.Example}}{{end}}</textarea>
+			</label>
+			{{else}}
+			<label>{{.Name}} <span class="param-type">(in {{.In}}{{if .Optional}}, optional{{end}})</span>
+				<input type="text" data-in="{{.In}}" data-name="{{.Name}}" placeholder="{{.Schema.Type}}">
+			</label>
+			{{end}}
+			{{end}}
+			<button type="submit">Send</button>
+		</form>
+		<div class="response" hidden>
+			<p class="status"></p>
+			<pre class="headers"></pre>
+			<pre class="body"></pre>
+		</div>
+	</details>
+</div>
+{{end}}
+{{define "toc"}}
+<ul>
+	{{range .Endpoints}}
+	<li><a href="#{{.Anchor}}">{{.Method}} {{.FullPath}}</a></li>
+	{{end}}
+	{{range .Subroutes}}
+	<li>{{.FullPath}}{{template "toc" .}}</li>
+	{{end}}
+</ul>
+{{end}}
+{{define "subroute"}}
+<section class="subroute">
+	<h3>Path: {{.FullPath}}</h3>
+	{{range .Endpoints}}
+	{{template "endpoint" .}}
+	{{end}}
+	{{range .Subroutes}}
+	{{template "subroute" .}}
+	{{end}}
+</section>
+{{end}}
 <!DOCTYPE html>
 <html>
 <head>
@@ -12,50 +94,121 @@ const tmpl = `
         h3 { color: #777; }
         .endpoint { margin-bottom: 20px; margin-left: 1em }
         .method { font-weight: bold; color: #007BFF; }
+        .method a { color: inherit; text-decoration: none; }
+        .subroute { margin-left: 1em; }
+        .toc ul { list-style: none; padding-left: 1em; }
+        .tag { background: #e7f1ff; border-radius: 3px; padding: 1px 6px; margin-right: 4px; font-size: 0.9em; }
         .parameters { margin-left: 20px; }
         .parameters li { margin-bottom: 5px; }
         .param-name { font-weight: bold; }
         .param-type { color: #555; font-style: italic; }
+        .parameters code, .requires code { background: #f3f3f3; padding: 1px 4px; margin-right: 4px; }
+        .try { display: none; margin-left: 20px; }
+        .interactive .try { display: block; }
+        .try label { display: block; margin: 6px 0; }
+        .try input, .try textarea { display: block; width: 100%; box-sizing: border-box; font-family: monospace; }
+        .try pre { background: #f3f3f3; padding: 8px; overflow-x: auto; }
+        .status { font-weight: bold; }
     </style>
 </head>
-<body>
+<body class="{{if .Interactive}}interactive{{end}}">
     <h1>{{.Title}}</h1>
     <p>{{.Summary}}</p>
+    {{with .Description}}<p>{{.}}</p>{{end}}
     <p><strong>Version:</strong> {{.Version}}</p>
+    <nav class="toc">
+        <h2>Contents</h2>
+        {{template "toc" .Root}}
+        {{if .Tags}}
+        <h3>By tag</h3>
+        <ul>
+            {{range .Tags}}
+            <li>{{.Tag}}
+                <ul>
+                    {{range .Endpoints}}
+                    <li><a href="#{{.Anchor}}">{{.Method}} {{.FullPath}}</a></li>
+                    {{end}}
+                </ul>
+            </li>
+            {{end}}
+        </ul>
+        {{end}}
+    </nav>
+
     <h2>Routes</h2>
 
-	{{range .Endpoints}}
-	<div class="endpoint">
-		<p class="method">{{.Method}} {{.Path}}</p>
-		<p>{{.Description}}</p>
-		{{if .Parameters}}
-		<p><strong>Parameters:</strong></p>
-		<ul class="parameters">
-			{{range .Parameters}}
-			<li><span class="param-name">{{.Name}}</span> (in {{.In}}) - {{.Description}} <span class="param-type">[{{.Schema.Type}}]</span></li>
-			{{end}}
-		</ul>
-		{{end}}
-	</div>
+	{{range .Root.Endpoints}}
+	{{template "endpoint" .}}
 	{{end}}
 
-    {{range .Subroutes}}
-        <h3>Path: {{.Path}}</h3>
-        {{range .Endpoints}}
-        <div class="endpoint">
-            <p class="method">{{.Method}} {{.Path}}</p>
-            <p>{{.Description}}</p>
-            {{if .Parameters}}
-            <p><strong>Parameters:</strong></p>
-            <ul class="parameters">
-                {{range .Parameters}}
-                <li><span class="param-name">{{.Name}}</span> (in {{.In}}) - {{.Description}} <span class="param-type">[{{.Schema.Type}}]</span></li>
-                {{end}}
-            </ul>
-            {{end}}
-        </div>
-        {{end}}
+    {{range .Root.Subroutes}}
+    {{template "subroute" .}}
     {{end}}
+
+    <script>
+    async function send(form) {
+        let path = form.dataset.path;
+        const query = new URLSearchParams();
+        const formBody = new URLSearchParams();
+        const headers = new Headers();
+        let body;
+        for (const input of form.querySelectorAll("[data-in]")) {
+            const name = input.dataset.name, value = input.value;
+            switch (input.dataset.in) {
+            case "path":
+                path = path.replace(new RegExp("{" + name + "(:[^}]*)?}"), encodeURIComponent(value));
+                break;
+            case "query":
+                if (value !== "") query.append(name, value);
+                break;
+            case "header":
+                if (value !== "") headers.set(name, value);
+                break;
+            case "form":
+                if (value !== "") formBody.append(name, value);
+                break;
+            case "jsonbody":
+                headers.set("Content-Type", "application/json");
+                body = value;
+                break;
+            case "body":
+                body = value;
+                break;
+            }
+        }
+        if (body === undefined && [...formBody].length > 0) {
+            headers.set("Content-Type", "application/x-www-form-urlencoded");
+            body = formBody.toString();
+        }
+        const method = form.dataset.method;
+        const url = path + ([...query].length > 0 ? "?" + query.toString() : "");
+        return fetch(url, {method: method, headers: headers, body: method === "GET" ? undefined : body});
+    }
+
+    function tryIt(form) {
+        const result = form.nextElementSibling;
+        const status = result.querySelector(".status");
+        const headers = result.querySelector(".headers");
+        const body = result.querySelector(".body");
+        result.hidden = false;
+        status.textContent = "Sending...";
+        headers.textContent = "";
+        body.textContent = "";
+        send(form).then(async (response) => {
+            status.textContent = response.status + " " + response.statusText;
+            headers.textContent = [...response.headers].map(([k, v]) => k + ": " + v).join("\n");
+            const text = await response.text();
+            try {
+                body.textContent = JSON.stringify(JSON.parse(text), null, 2);
+            } catch (e) {
+                body.textContent = text;
+            }
+        }).catch((err) => {
+            status.textContent = "Request failed: " + err;
+        });
+        return false;
+    }
+    </script>
 </body>
 </html>
 `
@@ -0,0 +1,9 @@
+#!/bin/sh
+# Vendors the Swagger UI bundle and its licenses into assets/, they are
+# embedded into the binary by the next build.
+set -e
+SWAGGER_UI_VERSION=${SWAGGER_UI_VERSION:-5.18.2}
+cd "$(dirname "$0")/assets"
+for file in swagger-ui-bundle.js swagger-ui-bundle.js.LICENSE.txt swagger-ui.css LICENSE; do
+	curl -fsSL -o "swagger-ui/$file" "https://unpkg.com/swagger-ui-dist@$SWAGGER_UI_VERSION/$file"
+done
@@ -0,0 +1,79 @@
+// Package viewer serves the Swagger UI documentation viewer from assets
+// embedded into the binary, so it works without internet access.
+//
+// Swagger UI 5.18.2 is vendored in assets/swagger-ui, run
+//
+//	go generate ./docgen/viewer
+//
+// to update it, or serve a copy vendored into your own module with With.
+package viewer
+
+//go:generate sh fetch.sh
+
+import (
+	"bytes"
+	"embed"
+	"html/template"
+	"io/fs"
+	"net/http"
+	"strings"
+)
+
+//go:embed assets
+var assets embed.FS
+
+type Viewer struct {
+	Name string
+	// bundle is the vendored script the index page loads
+	bundle string
+	files  fs.FS
+	index  *template.Template
+}
+
+var SwaggerUI = load("Swagger UI", "swagger-ui", "swagger-ui-bundle.js")
+
+func load(name, dir, bundle string) *Viewer {
+	files, err := fs.Sub(assets, "assets/"+dir)
+	if err != nil {
+		panic(err)
+	}
+	return &Viewer{
+		Name:   name,
+		bundle: bundle,
+		files:  files,
+		index:  template.Must(template.ParseFS(files, "index.html")),
+	}
+}
+
+// With returns a copy of the viewer that serves its bundle from files, e.g.
+// an embed.FS of your own copy of swagger-ui-dist.
+func (v *Viewer) With(files fs.FS) *Viewer {
+	return &Viewer{Name: v.Name, bundle: v.bundle, files: files, index: v.index}
+}
+
+// Bundled reports whether the viewer bundle has been vendored, the viewer
+// only shows a hint to run go generate without it.
+func (v *Viewer) Bundled() bool {
+	_, err := fs.Stat(v.files, v.bundle)
+	return err == nil
+}
+
+// Handler serves the viewer for the OpenAPI document at specURL, it expects
+// to be mounted with its prefix stripped.
+func (v *Viewer) Handler(title, specURL string) http.Handler {
+	var index bytes.Buffer
+	err := v.index.Execute(&index, struct{ Title, SpecURL string }{title, specURL})
+	if err != nil {
+		panic(err)
+	}
+	files := http.FileServer(http.FS(v.files))
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path := strings.TrimPrefix(r.URL.Path, "/")
+		if path == "" || path == "index.html" {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(index.Bytes())
+			return
+		}
+		files.ServeHTTP(w, r)
+	})
+}
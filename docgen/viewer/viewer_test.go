@@ -0,0 +1,46 @@
+package viewer_test
+
+import (
+	"github.com/nokusukun/faust/docgen/viewer"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func get(h http.Handler, path string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", path, nil))
+	return w
+}
+
+func TestSwaggerUIIsVendored(t *testing.T) {
+	if !viewer.SwaggerUI.Bundled() {
+		t.Fatal("the Swagger UI bundle is not embedded")
+	}
+	h := viewer.SwaggerUI.Handler("My API", "/openapi.json")
+	index := get(h, "/")
+	if index.Code != http.StatusOK || !strings.Contains(index.Body.String(), `SwaggerUIBundle({url: "\/openapi.json"`) {
+		t.Errorf("index: got status %d: %s", index.Code, index.Body)
+	}
+	for _, file := range []string{"/swagger-ui-bundle.js", "/swagger-ui.css"} {
+		if w := get(h, file); w.Code != http.StatusOK || w.Body.Len() < 10_000 {
+			t.Errorf("%s: got status %d and %d bytes", file, w.Code, w.Body.Len())
+		}
+	}
+}
+
+func TestWith(t *testing.T) {
+	if viewer.SwaggerUI.With(fstest.MapFS{}).Bundled() {
+		t.Error("an empty copy is reported as bundled")
+	}
+	files := fstest.MapFS{"swagger-ui-bundle.js": {Data: []byte("SwaggerUIBundle = {}")}}
+	swagger := viewer.SwaggerUI.With(files)
+	if !swagger.Bundled() {
+		t.Fatal("the bundle of With is not used")
+	}
+	if w := get(swagger.Handler("My API", "/openapi.json"), "/swagger-ui-bundle.js"); w.Body.String() != "SwaggerUIBundle = {}" {
+		t.Errorf("got %q", w.Body)
+	}
+}
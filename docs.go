@@ -0,0 +1,159 @@
+package faust
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/nokusukun/faust/docgen"
+	"github.com/nokusukun/faust/openapi"
+	"net/http"
+	"strings"
+)
+
+// DocsConfig configures the documentation routes of an API, empty paths use
+// the defaults.
+type DocsConfig struct {
+	// JSONPath serves the API description, /docs.json by default.
+	JSONPath string
+	// HTMLPath serves the HTML documentation, /docs.html by default.
+	HTMLPath string
+	// OpenAPIPath serves the OpenAPI document, /openapi.json by default.
+	OpenAPIPath string
+	// Disabled removes every documentation route, including viewers.
+	Disabled bool
+	// Middlewares wrap every documentation route, e.g. to put the docs
+	// behind authentication.
+	Middlewares []mux.MiddlewareFunc
+}
+
+func (config DocsConfig) withDefaults() DocsConfig {
+	if config.JSONPath == "" {
+		config.JSONPath = "/docs.json"
+	}
+	if config.HTMLPath == "" {
+		config.HTMLPath = "/docs.html"
+	}
+	if config.OpenAPIPath == "" {
+		config.OpenAPIPath = "/openapi.json"
+	}
+	return config
+}
+
+// Docs configures the documentation routes, they are registered when the API
+// starts serving. The documentation covers the whole API, Docs panics on a
+// subrouter.
+func (api *API) Docs(config DocsConfig) *API {
+	if api.parent != nil {
+		panic("faust: Docs can only be configured on the root API")
+	}
+	api.checkNotBuilt()
+	api.docs = config
+	return api
+}
+
+// DocsViewer serves a documentation UI for the OpenAPI document at specURL,
+// see the docgen/viewer package for the bundled Swagger UI.
+type DocsViewer interface {
+	Handler(title, specURL string) http.Handler
+}
+
+type docsViewer struct {
+	path   string
+	viewer DocsViewer
+}
+
+// Viewer mounts viewer at path, e.g. api.Viewer("/swagger", viewer.SwaggerUI).
+// Viewers load the OpenAPI document from DocsConfig.OpenAPIPath.
+func (api *API) Viewer(path string, viewer DocsViewer) *API {
+	api.checkNotBuilt()
+	api.viewers = append(api.viewers, docsViewer{path: "/" + strings.Trim(path, "/"), viewer: viewer})
+	return api
+}
+
+func (api *API) docsHandler(h http.Handler) http.Handler {
+	// we want the middleware to be executed in reverse order
+	for i := len(api.docs.Middlewares) - 1; i >= 0; i-- {
+		h = api.docs.Middlewares[i](h)
+	}
+	return h
+}
+
+// registerDocs adds the documentation routes of the API.
+func (api *API) registerDocs() {
+	if api.docs.Disabled {
+		return
+	}
+	config := api.docs.withDefaults()
+	api.Mux.Handle(config.JSONPath, api.docsHandler(api.docsRoute("docs.json", "application/json", func() ([]byte, error) {
+		return json.Marshal(api)
+	}))).Methods("GET")
+	api.Mux.Handle(config.HTMLPath, api.docsHandler(api.docsRoute("docs.html", "text/html", func() ([]byte, error) {
+		apiDoc, err := api.apiDoc()
+		if err != nil {
+			return nil, err
+		}
+		return []byte(docgen.GenerateHTML(apiDoc, docgen.Options{Interactive: true})), nil
+	}))).Methods("GET")
+	api.Mux.Handle(config.OpenAPIPath, api.docsHandler(api.docsRoute("openapi.json", "application/json", func() ([]byte, error) {
+		doc, err := api.OpenAPI()
+		if err != nil {
+			return nil, err
+		}
+		return json.Marshal(doc)
+	}))).Methods("GET")
+
+	title := api.Title
+	if title == "" {
+		title = "API Documentation"
+	}
+	api.registerViewers(title, config.OpenAPIPath, api.docsHandler)
+}
+
+// docsRoute serves the document generated by generate, failures are logged
+// and answered with a 500.
+func (api *API) docsRoute(name, contentType string, generate func() ([]byte, error)) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := generate()
+		if err != nil {
+			api.logger().Error("generating "+name, "error", err)
+			WriteError(w, r, NewError(http.StatusInternalServerError, "internal_error", errors.New("internal server error")))
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Write(body)
+	})
+}
+
+func (api *API) registerViewers(title, specURL string, wrap func(http.Handler) http.Handler) {
+	for _, v := range api.viewers {
+		full := api.prefix() + v.path
+		handler := http.StripPrefix(full, v.viewer.Handler(title, specURL))
+		// relative asset URLs need the trailing slash
+		api.Mux.Handle(v.path, wrap(http.RedirectHandler(full+"/", http.StatusMovedPermanently))).Methods("GET")
+		api.Mux.PathPrefix(v.path + "/").Handler(wrap(handler)).Methods("GET")
+	}
+	for _, sub := range api.Subrouters {
+		sub.registerViewers(title, specURL, wrap)
+	}
+}
+
+// OpenAPI describes the API as an OpenAPI 3 document, e.g. for client
+// generators.
+func (api *API) OpenAPI() (*openapi.Document, error) {
+	apiDoc, err := api.apiDoc()
+	if err != nil {
+		return nil, err
+	}
+	return docgen.OpenAPI(apiDoc), nil
+}
+
+// apiDoc converts the API into the documentation model of docgen.
+func (api *API) apiDoc() (docgen.APIDoc, error) {
+	var apiDoc docgen.APIDoc
+	jsonData, err := json.Marshal(api)
+	if err != nil {
+		return apiDoc, err
+	}
+	err = json.Unmarshal(jsonData, &apiDoc)
+	return apiDoc, err
+}
@@ -0,0 +1,115 @@
+package faust_test
+
+import (
+	"encoding/json"
+	"errors"
+	"github.com/gorilla/mux"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/param"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func docsAPI() *faust.API {
+	api := faust.New(faust.APIInfo{Title: "Docs"})
+	api.Subrouter("/v1").Get("/items", func(e *faust.Endpoint) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {}
+	})
+	return api
+}
+
+func TestDocsRoutes(t *testing.T) {
+	api := docsAPI()
+	for path, contentType := range map[string]string{
+		"/docs.json":    "application/json",
+		"/docs.html":    "text/html",
+		"/openapi.json": "application/json",
+	} {
+		w := send(api, "GET", path, nil)
+		if w.Code != http.StatusOK || w.Header().Get("Content-Type") != contentType || !strings.Contains(w.Body.String(), "/items") {
+			t.Errorf("%s: got status %d, Content-Type %q: %.100s", path, w.Code, w.Header().Get("Content-Type"), w.Body)
+		}
+	}
+}
+
+func TestDocsConfig(t *testing.T) {
+	api := docsAPI().Docs(faust.DocsConfig{
+		JSONPath: "/internal/docs.json",
+		Middlewares: []mux.MiddlewareFunc{func(next http.Handler) http.Handler {
+			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Header.Get("Authorization") == "" {
+					w.WriteHeader(http.StatusUnauthorized)
+					return
+				}
+				next.ServeHTTP(w, r)
+			})
+		}},
+	})
+	if w := send(api, "GET", "/docs.json", nil); w.Code != http.StatusNotFound {
+		t.Errorf("default path: got status %d, want 404", w.Code)
+	}
+	if w := send(api, "GET", "/internal/docs.json", nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("without credentials: got status %d, want 401", w.Code)
+	}
+	if w := send(api, "GET", "/internal/docs.json", map[string]string{"Authorization": "admin"}); w.Code != http.StatusOK {
+		t.Errorf("with credentials: got status %d, want 200", w.Code)
+	}
+
+	disabled := docsAPI().Docs(faust.DocsConfig{Disabled: true})
+	if w := send(disabled, "GET", "/openapi.json", nil); w.Code != http.StatusNotFound {
+		t.Errorf("disabled: got status %d, want 404", w.Code)
+	}
+}
+
+func TestDocsOnSubrouter(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("Docs on a subrouter did not panic")
+		}
+	}()
+	faust.New().Subrouter("/v1").Docs(faust.DocsConfig{Disabled: true})
+}
+
+// brokenParam fails to be documented.
+type brokenParam struct{}
+
+func (brokenParam) Use(r *http.Request) error { return nil }
+func (brokenParam) Dispose(r *http.Request)   {}
+func (brokenParam) MarshalJSON() ([]byte, error) {
+	return nil, errors.New("broken")
+}
+
+func TestDocsGenerationError(t *testing.T) {
+	api := faust.New()
+	api.Get("/items", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Params = append(e.Params, brokenParam{})
+		return func(w http.ResponseWriter, r *http.Request) {}
+	})
+	for _, path := range []string{"/docs.json", "/docs.html", "/openapi.json"} {
+		w := send(api, "GET", path, nil)
+		var problem struct{ Type string }
+		json.Unmarshal(w.Body.Bytes(), &problem)
+		if w.Code != http.StatusInternalServerError || problem.Type != "internal_error" {
+			t.Errorf("%s: got status %d: %s", path, w.Code, w.Body)
+		}
+	}
+}
+
+func TestOpenAPIPathTemplates(t *testing.T) {
+	api := faust.New()
+	api.Subrouter("/{org}").Get("/codes/{code:[a-z]{3}}", func(e *faust.Endpoint) http.HandlerFunc {
+		param.Path[string](e, "org")
+		param.Path[string](e, "code")
+		return func(w http.ResponseWriter, r *http.Request) {}
+	})
+	var doc struct {
+		Paths map[string]any `json:"paths"`
+	}
+	if err := json.Unmarshal(send(api, "GET", "/openapi.json", nil).Body.Bytes(), &doc); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := doc.Paths["/{org}/codes/{code}"]; !ok || len(doc.Paths) != 1 {
+		t.Errorf("got paths %v, want the variable patterns stripped", doc.Paths)
+	}
+}
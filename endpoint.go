@@ -1,24 +1,126 @@
 package faust
 
 import (
+	"context"
 	"encoding/json"
 	"github.com/gorilla/mux"
 	"net/http"
+	"reflect"
+	"time"
 )
 
 type EndpointInfo struct {
-	Method      string `json:"method,omitempty"`
-	Path        string `json:"path,omitempty"`
-	Name        string `json:"name,omitempty"`
-	Description string `json:"description,omitempty"`
+	Method      string   `json:"method,omitempty"`
+	Path        string   `json:"path,omitempty"`
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
 }
 
 type Endpoint struct {
+	// accessed atomically, kept first for 64-bit alignment
+	inFlight int64
+	queued   int64
 	EndpointInfo
-	Params      []IParam `json:"parameters,omitempty"`
-	middlewares []mux.MiddlewareFunc
-	httpHandler http.HandlerFunc
-	OnError     func(w http.ResponseWriter, r *http.Request, err error) `json:"-"`
+	Params        []IParam       `json:"parameters,omitempty"`
+	Authorization *Requirements  `json:"authorization,omitempty"`
+	Responses     []ResponseInfo `json:"responses,omitempty"`
+	middlewares   []mux.MiddlewareFunc
+	httpHandler   http.HandlerFunc
+	api           *API
+	rateLimit     *RateLimitPolicy
+	concurrency   *concurrencyLimit
+	timeout       time.Duration
+	OnError       func(w http.ResponseWriter, r *http.Request, err error) `json:"-"`
+}
+
+func (e *Endpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	start := time.Now()
+	rw := &responseWriter{ResponseWriter: w}
+	w = rw
+	state := &requestState{endpoint: e}
+	if config := e.api.requestIDConfig(); config != nil {
+		state.requestID = config.resolve(w, r)
+	}
+	r = r.WithContext(context.WithValue(r.Context(), requestStateKey{}, state))
+	tracer := e.api.tracerFor()
+	if tracer != nil {
+		var endSpan func()
+		r, endSpan = e.startServerSpan(tracer, rw, r)
+		defer endSpan()
+	}
+	defer e.logAccess(rw, r, start)
+	if metrics := e.api.metricsCollector(); metrics != nil {
+		defer func() {
+			metrics.observe(e, rw, time.Since(start))
+		}()
+	}
+	defer e.recover(w, r)
+	if cors := e.api.corsConfig(); cors != nil {
+		cors.handleActual(w, r)
+	}
+	if err := e.limit(w, r); err != nil {
+		e.fail(w, r, err)
+		return
+	}
+	release, err := e.acquire(r)
+	if err != nil {
+		e.fail(w, r, err)
+		return
+	}
+	h := http.Handler(http.HandlerFunc(e.serve))
+	// we want the middleware to be executed in reverse order
+	for i := len(e.middlewares) - 1; i >= 0; i-- {
+		h = e.middlewares[i](h)
+		if tracer != nil {
+			h = e.traceMiddleware(funcName(e.middlewares[i]), h)
+		}
+	}
+	e.withTimeout(w, r, h, release)
+}
+
+// serve runs after the endpoint middlewares so that parameters and the
+// handler see the same request, even if a middleware replaced its context.
+func (e *Endpoint) serve(w http.ResponseWriter, r *http.Request) {
+	defer func() {
+		if config := e.api.accessLogConfig(); config != nil && config.Params {
+			e.captureParams(r)
+		}
+		go e.Dispose(r)
+	}()
+	if err := e.authorize(r); err != nil {
+		e.fail(w, r, err)
+		return
+	}
+	if e.Use(w, r) {
+		e.httpHandler(w, r)
+	}
+}
+
+// MarshalJSON adds the policies the endpoint inherits from its API to the
+// documented endpoint.
+func (e *Endpoint) MarshalJSON() ([]byte, error) {
+	type endpoint Endpoint
+	return json.Marshal(struct {
+		*endpoint
+		RateLimit   *RateLimitPolicy  `json:"rate_limit,omitempty"`
+		Concurrency *concurrencyLimit `json:"concurrency,omitempty"`
+		Timeout     string            `json:"timeout,omitempty"`
+		Tags        []string          `json:"tags,omitempty"`
+	}{
+		endpoint:    (*endpoint)(e),
+		RateLimit:   e.rateLimitPolicy(),
+		Concurrency: e.concurrency,
+		Timeout:     durationString(e.timeoutDuration()),
+		Tags:        e.tags(),
+	})
+}
+
+func durationString(d time.Duration) string {
+	if d <= 0 {
+		return ""
+	}
+	return d.String()
 }
 
 func (e *Endpoint) Middlewares(middlewares ...mux.MiddlewareFunc) *Endpoint {
@@ -36,9 +138,66 @@ func (e *Endpoint) Description(name string) *Endpoint {
 	return e
 }
 
+// ResponseInfo documents a response of an endpoint, see param.Response for
+// declaring the response body type.
+type ResponseInfo struct {
+	Status      int    `json:"status"`
+	Description string `json:"description,omitempty"`
+	Schema      any    `json:"schema,omitempty"`
+	// Type is the Go type of the body, set by param.Response for the client
+	// generators.
+	Type reflect.Type `json:"-"`
+}
+
+// Returns documents a response of the endpoint with the schema of its body,
+// nil for responses without a body.
+func (e *Endpoint) Returns(status int, description string, schema any) *Endpoint {
+	e.Responses = append(e.Responses, ResponseInfo{Status: status, Description: description, Schema: schema})
+	return e
+}
+
+// Tags groups the endpoint in the documentation, in addition to the tags of
+// its subrouters.
+func (e *Endpoint) Tags(tags ...string) *Endpoint {
+	e.EndpointInfo.Tags = append(e.EndpointInfo.Tags, tags...)
+	return e
+}
+
+// tags returns the tags of the endpoint and its subrouters, outermost first.
+func (e *Endpoint) tags() []string {
+	var apis []*API
+	for a := e.api; a != nil; a = a.parent {
+		apis = append([]*API{a}, apis...)
+	}
+	var tags []string
+	seen := map[string]bool{}
+	add := func(tag string) {
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	for _, a := range apis {
+		for _, tag := range a.tags {
+			add(tag)
+		}
+	}
+	for _, tag := range e.EndpointInfo.Tags {
+		add(tag)
+	}
+	return tags
+}
+
 func (e *Endpoint) UseErr(r *http.Request) error {
 	for _, param := range e.Params {
-		if err := param.Use(r); err != nil {
+		_, span := e.startSpan(r.Context(), paramSpanName(param))
+		err := param.Use(r)
+		span.RecordError(err)
+		span.End()
+		if err != nil {
+			if metrics := e.api.metricsCollector(); metrics != nil {
+				metrics.validationFailed(e, param)
+			}
 			return err
 		}
 	}
@@ -48,22 +207,27 @@ func (e *Endpoint) UseErr(r *http.Request) error {
 func (e *Endpoint) Use(w http.ResponseWriter, r *http.Request) bool {
 	err := e.UseErr(r)
 	if err != nil {
-		if e.OnError != nil {
-			e.OnError(w, r, err)
-		} else {
-			w.Header().Set("Content-Type", "application/json")
-			w.WriteHeader(422)
-			json.NewEncoder(w).Encode(map[string]any{
-				"error": err.Error(),
-				"type":  "validation_error",
-			})
-		}
+		e.fail(w, r, err)
 		return false
 	}
 
 	return true
 }
 
+func (e *Endpoint) fail(w http.ResponseWriter, r *http.Request, err error) {
+	if e.OnError != nil {
+		e.OnError(w, r, err)
+		return
+	}
+	for a := e.api; a != nil; a = a.parent {
+		if a.OnError != nil {
+			a.OnError(w, r, err)
+			return
+		}
+	}
+	WriteError(w, r, err)
+}
+
 func (e *Endpoint) Dispose(r *http.Request) {
 	for _, param := range e.Params {
 		param.Dispose(r)
@@ -0,0 +1,64 @@
+package faust
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+// Error is an error that carries the HTTP status and error type faust should
+// respond with. Parameters and middlewares can return it to override the
+// default 422 validation_error response.
+type Error struct {
+	Status int
+	Type   string
+	Err    error
+	Header http.Header
+}
+
+func NewError(status int, errType string, err error) *Error {
+	return &Error{
+		Status: status,
+		Type:   errType,
+		Err:    err,
+	}
+}
+
+func (e *Error) Error() string {
+	if e.Err == nil {
+		return http.StatusText(e.Status)
+	}
+	return e.Err.Error()
+}
+
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// WriteError writes err as a faust JSON error body. Errors that are not a
+// *Error are treated as validation errors.
+func WriteError(w http.ResponseWriter, r *http.Request, err error) {
+	status, errType, message := 422, "validation_error", err.Error()
+	var fe *Error
+	if errors.As(err, &fe) {
+		status, errType = fe.Status, fe.Type
+		for k, v := range fe.Header {
+			w.Header()[k] = v
+		}
+	}
+	// don't leak panic values to clients
+	var pe *PanicError
+	if errors.As(err, &pe) {
+		message = "internal server error"
+	}
+	body := map[string]any{
+		"error": message,
+		"type":  errType,
+	}
+	if id := RequestIDFrom(r.Context()); id != "" {
+		body["request_id"] = id
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
@@ -0,0 +1,260 @@
+package fausttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/internal/pathvars"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+// Client sends requests to an API in process, without a socket.
+type Client struct {
+	api *faust.API
+	// handler serves the requests, the API unless it is wrapped
+	handler http.Handler
+	// Header is sent with every request, e.g. an Authorization header.
+	Header http.Header
+}
+
+func NewClient(api *faust.API) *Client {
+	return &Client{api: api, handler: api, Header: http.Header{}}
+}
+
+func (c *Client) Get(target string) *Request {
+	return c.Method(http.MethodGet, target)
+}
+
+func (c *Client) Post(target string) *Request {
+	return c.Method(http.MethodPost, target)
+}
+
+func (c *Client) Put(target string) *Request {
+	return c.Method(http.MethodPut, target)
+}
+
+func (c *Client) Patch(target string) *Request {
+	return c.Method(http.MethodPatch, target)
+}
+
+func (c *Client) Delete(target string) *Request {
+	return c.Method(http.MethodDelete, target)
+}
+
+// Method starts a request to target, the name of an endpoint or a path
+// template like "/items/{id}" whose variables are set with Path.
+func (c *Client) Method(method, target string) *Request {
+	return &Request{
+		client: c,
+		method: method,
+		target: target,
+		path:   map[string]any{},
+		query:  url.Values{},
+		header: c.Header.Clone(),
+	}
+}
+
+// Request is built with its methods and sent with Do. The values are sent
+// as given, invalid ones included, to test how the API rejects them.
+type Request struct {
+	client *Client
+	method string
+	target string
+	path   map[string]any
+	query  url.Values
+	header http.Header
+	body   []byte
+	err    error
+}
+
+func (r *Request) Path(name string, value any) *Request {
+	r.path[name] = value
+	return r
+}
+
+func (r *Request) Query(name string, value any) *Request {
+	r.query.Add(name, fmt.Sprint(value))
+	return r
+}
+
+func (r *Request) Header(name, value string) *Request {
+	r.header.Add(name, value)
+	return r
+}
+
+// JSON sends v encoded as JSON.
+func (r *Request) JSON(v any) *Request {
+	body, err := json.Marshal(v)
+	if err != nil {
+		r.err = fmt.Errorf("encoding the JSON body: %w", err)
+	}
+	return r.Body("application/json", body)
+}
+
+// Form sends values URL encoded.
+func (r *Request) Form(values url.Values) *Request {
+	return r.Body("application/x-www-form-urlencoded", []byte(values.Encode()))
+}
+
+func (r *Request) Body(contentType string, body []byte) *Request {
+	r.header.Set("Content-Type", contentType)
+	r.body = body
+	return r
+}
+
+// Do sends the request. Errors building it, e.g. a missing path variable,
+// are set as the Err of the response and fail its assertions.
+func (r *Request) Do() *Response {
+	target, err := r.url()
+	if err == nil {
+		err = r.err
+	}
+	if err != nil {
+		return &Response{Err: fmt.Errorf("%s %s: %w", r.method, r.target, err)}
+	}
+	request := httptest.NewRequest(r.method, target, bytes.NewReader(r.body))
+	for name, values := range r.header {
+		request.Header[name] = values
+	}
+	recorder := httptest.NewRecorder()
+	r.client.handler.ServeHTTP(recorder, request)
+	return &Response{
+		Request: request,
+		Status:  recorder.Code,
+		Header:  recorder.Header(),
+		Body:    recorder.Body.Bytes(),
+	}
+}
+
+// url resolves the target to the path template of the endpoint with that
+// name, or takes it as a template, and fills in the path variables.
+func (r *Request) url() (string, error) {
+	template := r.target
+	if !strings.HasPrefix(template, "/") {
+		found := false
+		for _, route := range r.client.api.Routes() {
+			if route.Name == r.target {
+				template, found = route.Path, true
+				break
+			}
+		}
+		if !found {
+			return "", errors.New("no endpoint with that name")
+		}
+	}
+	used := map[string]bool{}
+	var missing []string
+	path := pathvars.Replace(template, func(v pathvars.Variable) string {
+		value, ok := r.path[v.Name]
+		if !ok {
+			missing = append(missing, v.Name)
+			return "{" + v.Name + "}"
+		}
+		used[v.Name] = true
+		return url.PathEscape(fmt.Sprint(value))
+	})
+	if len(missing) > 0 {
+		return "", fmt.Errorf("missing path variables %s", strings.Join(missing, ", "))
+	}
+	for name := range r.path {
+		if !used[name] {
+			return "", fmt.Errorf("path variable %q is not in %s", name, template)
+		}
+	}
+	if len(r.query) > 0 {
+		path += "?" + r.query.Encode()
+	}
+	return path, nil
+}
+
+type Response struct {
+	Request *http.Request
+	Status  int
+	Header  http.Header
+	Body    []byte
+	// Err is set when the request couldn't be sent.
+	Err error
+}
+
+// Decode decodes the JSON body into v.
+func (r *Response) Decode(v any) error {
+	if r.Err != nil {
+		return r.Err
+	}
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		return fmt.Errorf("decoding %d response %q: %w", r.Status, r.Body, err)
+	}
+	return nil
+}
+
+// Decode decodes the JSON body of the response as a T, failing t when the
+// request failed or the body isn't a T.
+func Decode[T any](t testing.TB, r *Response) T {
+	t.Helper()
+	var v T
+	if err := r.Decode(&v); err != nil {
+		t.Fatalf("fausttest: %v", err)
+	}
+	return v
+}
+
+// Problem is the body of faust error responses.
+type Problem struct {
+	Error     string `json:"error"`
+	Type      string `json:"type"`
+	RequestID string `json:"request_id,omitempty"`
+}
+
+// Problem decodes the response as a faust error, it fails for bodies that
+// aren't JSON or lack the error message or type.
+func (r *Response) Problem() (Problem, error) {
+	var problem Problem
+	if r.Err != nil {
+		return problem, r.Err
+	}
+	if mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type")); mediaType != "application/json" {
+		return problem, fmt.Errorf("%d response has Content-Type %q, not a JSON error: %q", r.Status, r.Header.Get("Content-Type"), r.Body)
+	}
+	if err := r.Decode(&problem); err != nil {
+		return problem, err
+	}
+	if problem.Error == "" || problem.Type == "" {
+		return problem, fmt.Errorf("%d response is not a faust error, it lacks the error or type: %s", r.Status, r.Body)
+	}
+	return problem, nil
+}
+
+// AssertStatus fails t unless the response has the status.
+func (r *Response) AssertStatus(t testing.TB, status int) *Response {
+	t.Helper()
+	if r.Err != nil {
+		t.Fatalf("fausttest: %v", r.Err)
+	}
+	if r.Status != status {
+		t.Errorf("fausttest: %s %s: got status %d, want %d, body: %s", r.Request.Method, r.Request.URL, r.Status, status, r.Body)
+	}
+	return r
+}
+
+// AssertProblem fails t unless the response is a faust error with the status
+// and error type, e.g. 422 and "validation_error".
+func (r *Response) AssertProblem(t testing.TB, status int, errType string) Problem {
+	t.Helper()
+	r.AssertStatus(t, status)
+	problem, err := r.Problem()
+	if err != nil {
+		t.Errorf("fausttest: %s %s: %v", r.Request.Method, r.Request.URL, err)
+		return problem
+	}
+	if problem.Type != errType {
+		t.Errorf("fausttest: %s %s: got error type %q, want %q: %s", r.Request.Method, r.Request.URL, problem.Type, errType, problem.Error)
+	}
+	return problem
+}
@@ -0,0 +1,177 @@
+package fausttest_test
+
+import (
+	"errors"
+	"fmt"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/fausttest"
+	"github.com/nokusukun/faust/param"
+	"net/http"
+	"net/url"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// recorder collects the failures of assertions, Fatalf ends the goroutine
+// like it ends a test.
+type recorder struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func (r *recorder) Fatalf(format string, args ...any) {
+	r.Errorf(format, args...)
+	runtime.Goexit()
+}
+
+// failures returns what assert reported.
+func failures(t *testing.T, assert func(t testing.TB)) []string {
+	r := &recorder{TB: t}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		assert(r)
+	}()
+	<-done
+	return r.failures
+}
+
+func clientAPI() *faust.API {
+	api := faust.New()
+	orgs := api.Subrouter("/orgs/{org}")
+	orgs.Get("/items/{id:[0-9]+}", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Name("getItem")
+		org := param.Path[string](e, "org")
+		id := param.Path[int](e, "id")
+		page := param.Query[int](e, "page").Optional()
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "application/json")
+			fmt.Fprintf(w, `{"name": "%s/%d", "page": %d, "tenant": %q}`, org.Value(r), id.Value(r), page.Value(r), r.Header.Get("X-Tenant"))
+		}
+	})
+	api.Get("/codes/{code:[a-z]{3}}", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Name("getCode")
+		code := param.Path[string](e, "code")
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(code.Value(r)))
+		}
+	})
+	api.Post("/items", func(e *faust.Endpoint) http.HandlerFunc {
+		name := param.Form[string](e, "name")
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusCreated)
+			fmt.Fprintf(w, `{"name": %q}`, name.Value(r))
+		}
+	})
+	api.Get("/plain", func(e *faust.Endpoint) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, "teapot", http.StatusTeapot)
+		}
+	})
+	return api
+}
+
+type orgItem struct {
+	Name   string `json:"name"`
+	Page   int    `json:"page"`
+	Tenant string `json:"tenant"`
+}
+
+func TestClient(t *testing.T) {
+	client := fausttest.NewClient(clientAPI())
+	client.Header.Set("X-Tenant", "acme")
+
+	tests := []struct {
+		name    string
+		request *fausttest.Request
+		want    orgItem
+	}{
+		{"by name", client.Get("getItem").Path("org", "a b").Path("id", 7), orgItem{Name: "a b/7", Tenant: "acme"}},
+		{"by template", client.Get("/orgs/{org}/items/{id:[0-9]+}").Path("org", "x").Path("id", 1).Query("page", 2), orgItem{Name: "x/1", Page: 2, Tenant: "acme"}},
+		{"header", client.Get("getItem").Path("org", "x").Path("id", 1).Header("X-Tenant", "other"), orgItem{Name: "x/1", Tenant: "acme"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resp := test.request.Do()
+			if got := fausttest.Decode[orgItem](t, resp.AssertStatus(t, http.StatusOK)); got != test.want {
+				t.Errorf("got %+v, want %+v", got, test.want)
+			}
+		})
+	}
+	// added headers come after the client's
+	if resp := client.Get("getItem").Path("org", "x").Path("id", 1).Header("X-Tenant", "other").Do(); resp.Request.Header.Values("X-Tenant")[1] != "other" {
+		t.Errorf("got headers %v", resp.Request.Header)
+	}
+	if got := client.Get("getCode").Path("code", "abc").Do().AssertStatus(t, http.StatusOK).Body; string(got) != "abc" {
+		t.Errorf("got %q from a nested brace pattern", got)
+	}
+	resp := client.Post("/items").Form(url.Values{"name": {"rex"}}).Do().AssertStatus(t, http.StatusCreated)
+	if got := fausttest.Decode[orgItem](t, resp); got.Name != "rex" {
+		t.Errorf("got %+v from the form", got)
+	}
+}
+
+func TestClientTargetErrors(t *testing.T) {
+	client := fausttest.NewClient(clientAPI())
+	tests := []struct {
+		request *fausttest.Request
+		want    string
+	}{
+		{client.Get("deleteItem"), "GET deleteItem: no endpoint with that name"},
+		{client.Get("getItem").Path("org", "x"), "GET getItem: missing path variables id"},
+		{client.Get("/orgs/{org}/items/{id:[0-9]+}"), "missing path variables org, id"},
+		{client.Get("getCode").Path("code", "abc").Path("id", 1), `path variable "id" is not in /codes/{code:[a-z]{3}}`},
+		{client.Post("/items").JSON(func() {}), "encoding the JSON body"},
+	}
+	for _, test := range tests {
+		resp := test.request.Do()
+		if resp.Err == nil || !strings.Contains(resp.Err.Error(), test.want) {
+			t.Errorf("got error %v, want one containing %q", resp.Err, test.want)
+		}
+		got := failures(t, func(t testing.TB) { resp.AssertStatus(t, http.StatusOK) })
+		if len(got) != 1 || !strings.Contains(got[0], test.want) {
+			t.Errorf("AssertStatus reported %q, want %q", got, test.want)
+		}
+	}
+}
+
+func TestProblem(t *testing.T) {
+	client := fausttest.NewClient(clientAPI())
+
+	resp := client.Get("getItem").Path("org", "x").Path("id", 1).Query("page", "two").Do()
+	problem := resp.AssertProblem(t, http.StatusUnprocessableEntity, "validation_error")
+	if !strings.Contains(problem.Error, `"two"`) {
+		t.Errorf("got problem %+v", problem)
+	}
+
+	tests := []struct {
+		name   string
+		resp   *fausttest.Response
+		status int
+		want   string
+	}{
+		{"other status", resp, http.StatusBadRequest, "got status 422, want 400"},
+		{"not JSON", client.Get("/plain").Do(), http.StatusTeapot, `has Content-Type "text/plain; charset=utf-8", not a JSON error`},
+		{"not an error", client.Get("getItem").Path("org", "x").Path("id", 1).Do(), http.StatusOK, "lacks the error or type"},
+		{"not sent", &fausttest.Response{Err: errors.New("broken")}, http.StatusOK, "broken"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := failures(t, func(t testing.TB) { test.resp.AssertProblem(t, test.status, "validation_error") })
+			if len(got) == 0 || !strings.Contains(strings.Join(got, "\n"), test.want) {
+				t.Errorf("AssertProblem reported %q, want %q", got, test.want)
+			}
+		})
+	}
+	got := failures(t, func(t testing.TB) { resp.AssertProblem(t, http.StatusUnprocessableEntity, "not_found") })
+	if len(got) != 1 || !strings.Contains(got[0], `got error type "validation_error", want "not_found"`) {
+		t.Errorf("AssertProblem reported %q for another error type", got)
+	}
+}
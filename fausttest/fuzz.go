@@ -0,0 +1,643 @@
+package fausttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/internal/panics"
+	"github.com/nokusukun/faust/internal/pathvars"
+	"math"
+	"math/rand"
+	"net/http"
+	"net/url"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+type FuzzOptions struct {
+	// Runs is the number of valid and of invalid requests sent to every
+	// endpoint, 20 by default.
+	Runs int
+	// Seed makes the requests reproducible, 0 picks one that is logged when
+	// a check fails.
+	Seed int64
+	// Header is sent with every request, e.g. credentials.
+	Header http.Header
+	// Skip excludes endpoints, e.g. ones with side effects.
+	Skip func(route faust.Route) bool
+	// BodySize is the size of the oversized bodies sent, 1 MiB by default.
+	BodySize int
+}
+
+// Fuzz sends generated requests to every endpoint of the API, derived from
+// the types of their parameters: valid ones, boundary values, oversized
+// bodies and invalid ones with missing required parameters, values of the
+// wrong type or malformed JSON. It checks that no request panics or gets a
+// 5xx response and that invalid requests get a 4xx faust error.
+func Fuzz(t *testing.T, api *faust.API, opts FuzzOptions) {
+	t.Helper()
+	opts = opts.withDefaults()
+	if opts.Seed == 0 {
+		opts.Seed = time.Now().UnixNano()
+	}
+	f, err := newFuzzer(api, opts)
+	if err != nil {
+		t.Fatalf("fausttest: %v", err)
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	for _, target := range f.targets {
+		target := target
+		t.Run(target.key, func(t *testing.T) {
+			for _, p := range target.params {
+				if p.in == "path" && len(p.valid) == 0 {
+					t.Skipf("fausttest: no generated value is accepted by path parameter %s", p.name)
+				}
+			}
+			failures := 0
+			for _, c := range f.cases(target, rng) {
+				if !f.check(t, c) {
+					failures++
+				}
+				// the first failures tell enough
+				if failures == 5 {
+					break
+				}
+			}
+			if failures > 0 {
+				t.Logf("fausttest: reproduce with FuzzOptions{Seed: %d}", opts.Seed)
+			}
+		})
+	}
+}
+
+// FuzzTarget fuzzes the API with Go's native fuzzing, seeded with the
+// requests Fuzz generates. Failing inputs are kept in testdata/fuzz by go
+// test and refer to endpoints by method and path:
+//
+//	func FuzzAPI(f *testing.F) {
+//		fausttest.FuzzTarget(f, myapi.New(), fausttest.FuzzOptions{})
+//	}
+func FuzzTarget(f *testing.F, api *faust.API, opts FuzzOptions) {
+	opts = opts.withDefaults()
+	if opts.Seed == 0 {
+		opts.Seed = 1
+	}
+	fz, err := newFuzzer(api, opts)
+	if err != nil {
+		f.Fatalf("fausttest: %v", err)
+	}
+	rng := rand.New(rand.NewSource(opts.Seed))
+	for _, target := range fz.targets {
+		for _, c := range fz.cases(target, rng) {
+			if len(c.body) <= 4096 {
+				f.Add(target.key, c.values.Encode(), c.body)
+			}
+		}
+	}
+	targets := map[string]*fuzzTarget{}
+	for _, target := range fz.targets {
+		targets[target.key] = target
+	}
+	f.Fuzz(func(t *testing.T, key, values string, body []byte) {
+		target, ok := targets[key]
+		if !ok {
+			t.Skip("no such endpoint")
+		}
+		parsed, err := url.ParseQuery(values)
+		if err != nil {
+			t.Skip("malformed values")
+		}
+		fz.check(t, fuzzCase{target: target, values: parsed, body: body})
+	})
+}
+
+func (opts FuzzOptions) withDefaults() FuzzOptions {
+	if opts.Runs == 0 {
+		opts.Runs = 20
+	}
+	if opts.BodySize == 0 {
+		opts.BodySize = 1 << 20
+	}
+	return opts
+}
+
+type fuzzer struct {
+	api     *faust.API
+	client  *Client
+	opts    FuzzOptions
+	targets []*fuzzTarget
+	// panics are reported by endpoints, which may outlive their request
+	// when they time out
+	mu     sync.Mutex
+	panics []*faust.PanicError
+}
+
+type fuzzTarget struct {
+	// key is the method and path template of the endpoint
+	key    string
+	route  faust.Route
+	params []*fuzzParam
+	// body is the type of the JSON body, if the endpoint reads one
+	body reflect.Type
+}
+
+// fuzzParam is a parameter the fuzzer can check values of.
+type fuzzParam struct {
+	in, name string
+	optional bool
+	checker  faust.ParamChecker
+	pattern  *regexp.Regexp
+	// valid and invalid are candidate values sorted by the parameter
+	valid, invalid []string
+}
+
+// key identifies the parameter in the values of a fuzz case.
+func (p *fuzzParam) key() string {
+	return p.in + ":" + p.name
+}
+
+// check reports why the parameter rejects value, a missing value is nil.
+func (p *fuzzParam) check(value *string) error {
+	if value == nil {
+		if p.optional && p.in != "path" {
+			return nil
+		}
+		return fmt.Errorf("missing required %s parameter %s", p.in, p.name)
+	}
+	if err := p.checker.ParamCheck(*value); err != nil {
+		return fmt.Errorf("%s parameter %s: %w", p.in, p.name, err)
+	}
+	return nil
+}
+
+// routable reports whether a path parameter value reaches the endpoint, the
+// router answers others itself.
+func (p *fuzzParam) routable(value string) bool {
+	return value != "" && value != "." && value != ".." && !strings.Contains(value, "/") &&
+		(p.pattern == nil || p.pattern.MatchString(value))
+}
+
+func newFuzzer(api *faust.API, opts FuzzOptions) (*fuzzer, error) {
+	if err := api.Build(); err != nil {
+		return nil, err
+	}
+	f := &fuzzer{api: api, client: NewClient(api), opts: opts}
+	f.client.handler = f.capturePanics(api)
+	if opts.Header != nil {
+		f.client.Header = opts.Header.Clone()
+	}
+	for _, route := range api.Routes() {
+		if opts.Skip != nil && opts.Skip(route) {
+			continue
+		}
+		target := &fuzzTarget{key: route.Method + " " + route.Path, route: route}
+		patterns := map[string]*regexp.Regexp{}
+		_, variables := pathvars.Split(route.Path)
+		for _, v := range variables {
+			if v.Pattern != "" {
+				patterns[v.Name] = regexp.MustCompile("^(?:" + v.Pattern + ")$")
+			}
+		}
+		for _, param := range route.Endpoint.Params {
+			describer, ok := param.(faust.ParamDescriber)
+			if !ok {
+				continue
+			}
+			in, name, _ := describer.ParamInfo()
+			typed, ok := param.(typedParam)
+			if !ok {
+				continue
+			}
+			if in == "jsonbody" {
+				target.body = typed.ParamType()
+				continue
+			}
+			checker, ok := param.(faust.ParamChecker)
+			if !ok {
+				continue
+			}
+			p := &fuzzParam{
+				in:       in,
+				name:     name,
+				optional: typed.ParamOptional(),
+				checker:  checker,
+				pattern:  patterns[name],
+			}
+			for _, value := range candidates(typed.ParamType(), enumOf(param)) {
+				if in == "path" && !p.routable(value) {
+					continue
+				}
+				if checker.ParamCheck(value) == nil {
+					p.valid = append(p.valid, value)
+				} else {
+					p.invalid = append(p.invalid, value)
+				}
+			}
+			target.params = append(target.params, p)
+		}
+		f.targets = append(f.targets, target)
+	}
+	return f, nil
+}
+
+// typedParam is implemented by parameters whose values can be generated,
+// e.g. param.EndpointParam.
+type typedParam interface {
+	ParamType() reflect.Type
+	ParamOptional() bool
+}
+
+// capturePanics records the panics of the endpoints serving the requests
+// to next, OnPanic of the API and its subrouters is still called.
+func (f *fuzzer) capturePanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r.WithContext(panics.WithObserver(r.Context(), f.observePanic)))
+	})
+}
+
+func (f *fuzzer) observePanic(err error) {
+	if pe, ok := err.(*faust.PanicError); ok {
+		f.recordPanic(pe)
+	}
+}
+
+func (f *fuzzer) recordPanic(pe *faust.PanicError) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.panics = append(f.panics, pe)
+}
+
+// lastPanic returns the last panic recorded after the first n.
+func (f *fuzzer) lastPanic(n int) *faust.PanicError {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.panics) > n {
+		return f.panics[len(f.panics)-1]
+	}
+	return nil
+}
+
+func (f *fuzzer) panicCount() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.panics)
+}
+
+type fuzzCase struct {
+	target *fuzzTarget
+	// values are the parameter values by "in:name", absent ones are missing
+	values url.Values
+	body   []byte
+}
+
+// cases generates the valid and invalid requests of an endpoint.
+func (f *fuzzer) cases(target *fuzzTarget, rng *rand.Rand) []fuzzCase {
+	var cases []fuzzCase
+	valid := func() fuzzCase {
+		c := fuzzCase{target: target, values: url.Values{}}
+		for _, p := range target.params {
+			if len(p.valid) == 0 || p.optional && p.in != "path" && rng.Intn(2) == 0 {
+				continue
+			}
+			c.values.Set(p.key(), p.valid[rng.Intn(len(p.valid))])
+		}
+		if target.body != nil {
+			c.body = randomJSON(target.body, rng)
+		}
+		return c
+	}
+	for i := 0; i < f.opts.Runs; i++ {
+		cases = append(cases, valid())
+	}
+	if target.body != nil {
+		c := valid()
+		// leading whitespace keeps the JSON valid
+		c.body = append(bytes.Repeat([]byte(" "), f.opts.BodySize), c.body...)
+		cases = append(cases, c)
+	}
+
+	type mutation func(c *fuzzCase) bool
+	var mutations []mutation
+	for _, p := range target.params {
+		p := p
+		if !p.optional && p.in != "path" {
+			mutations = append(mutations, func(c *fuzzCase) bool {
+				c.values.Del(p.key())
+				return true
+			})
+		}
+		if len(p.invalid) > 0 {
+			mutations = append(mutations, func(c *fuzzCase) bool {
+				c.values.Set(p.key(), p.invalid[rng.Intn(len(p.invalid))])
+				return true
+			})
+		}
+	}
+	if target.body != nil {
+		mutations = append(mutations, func(c *fuzzCase) bool {
+			c.body = malformedJSON[rng.Intn(len(malformedJSON))]
+			return true
+		}, func(c *fuzzCase) bool {
+			body, ok := wrongTypeJSON(target.body, c.body, rng)
+			c.body = body
+			return ok
+		})
+	}
+	if len(mutations) == 0 {
+		return cases
+	}
+	for i := 0; i < f.opts.Runs; i++ {
+		c := valid()
+		if mutations[rng.Intn(len(mutations))](&c) {
+			cases = append(cases, c)
+		}
+	}
+	return cases
+}
+
+// classify returns why the request is invalid, nil for valid ones, and
+// false for requests that can't be sent to the endpoint.
+func (c fuzzCase) classify() (invalid error, ok bool) {
+	for _, p := range c.target.params {
+		var value *string
+		if values, present := c.values[p.key()]; present && len(values) > 0 {
+			value = &values[0]
+		}
+		if p.in == "path" && (value == nil || !p.routable(*value)) {
+			return nil, false
+		}
+		if err := p.check(value); err != nil && invalid == nil {
+			invalid = err
+		}
+	}
+	if c.target.body != nil && invalid == nil {
+		// decoded the way param.Json does
+		err := json.NewDecoder(bytes.NewReader(c.body)).Decode(reflect.New(c.target.body).Interface())
+		if err != nil {
+			invalid = fmt.Errorf("JSON body: %w", err)
+		}
+	}
+	return invalid, true
+}
+
+func (f *fuzzer) send(c fuzzCase) *Response {
+	request := f.client.Method(c.target.route.Method, c.target.route.Path)
+	form := url.Values{}
+	for _, p := range c.target.params {
+		values, ok := c.values[p.key()]
+		if !ok || len(values) == 0 {
+			continue
+		}
+		switch p.in {
+		case "path":
+			request.Path(p.name, values[0])
+		case "query":
+			request.Query(p.name, values[0])
+		case "header":
+			request.Header(p.name, values[0])
+		case "form":
+			form.Set(p.name, values[0])
+		case "body":
+			request.Body("text/plain", []byte(values[0]))
+		}
+	}
+	switch {
+	case c.target.body != nil:
+		request.Body("application/json", c.body)
+	case len(form) > 0:
+		request.Form(form)
+	}
+	return request.Do()
+}
+
+// check sends the request and reports whether it passed the checks.
+func (f *fuzzer) check(t testing.TB, c fuzzCase) bool {
+	t.Helper()
+	invalid, ok := c.classify()
+	if !ok {
+		return true
+	}
+	panics := f.panicCount()
+	var resp *Response
+	func() {
+		defer func() {
+			if value := recover(); value != nil {
+				f.recordPanic(&faust.PanicError{Value: value, Method: c.target.route.Method, Path: c.target.route.Path})
+			}
+		}()
+		resp = f.send(c)
+	}()
+	describe := func() string {
+		body := c.body
+		if len(body) > 200 {
+			body = append(bytes.TrimSpace(body[:200]), "..."...)
+		}
+		s := c.target.route.Method + " " + c.target.route.Path
+		if resp != nil && resp.Request != nil {
+			s = resp.Request.Method + " " + resp.Request.URL.String()
+		}
+		if len(body) > 0 {
+			s += fmt.Sprintf(" with body %q", body)
+		}
+		if invalid != nil {
+			s += fmt.Sprintf(" (invalid: %v)", invalid)
+		}
+		return s
+	}
+	pe := f.lastPanic(panics)
+	switch {
+	case pe != nil:
+		t.Errorf("fausttest: %s panicked: %v\n%s", describe(), pe.Value, pe.Stack)
+	case resp.Err != nil:
+		t.Errorf("fausttest: %v", resp.Err)
+	case resp.Status >= 500:
+		t.Errorf("fausttest: %s got %d: %s", describe(), resp.Status, truncate(resp.Body))
+	case invalid != nil && resp.Status < 400:
+		t.Errorf("fausttest: %s got %d, want a 4xx error: %s", describe(), resp.Status, truncate(resp.Body))
+	case invalid != nil:
+		if _, err := resp.Problem(); err != nil {
+			t.Errorf("fausttest: %s: %v", describe(), err)
+		} else {
+			return true
+		}
+	default:
+		return true
+	}
+	return false
+}
+
+func truncate(body []byte) string {
+	if len(body) > 500 {
+		return string(body[:500]) + "..."
+	}
+	return string(body)
+}
+
+// enumOf returns the enum values the parameter documents.
+func enumOf(param faust.IParam) []string {
+	data, err := json.Marshal(param)
+	if err != nil {
+		return nil
+	}
+	var described struct {
+		Schema struct {
+			Enum []any `json:"enum"`
+		} `json:"schema"`
+	}
+	json.Unmarshal(data, &described)
+	var values []string
+	for _, value := range described.Schema.Enum {
+		if number, ok := value.(float64); ok {
+			values = append(values, strconv.FormatFloat(number, 'f', -1, 64))
+		} else {
+			values = append(values, fmt.Sprint(value))
+		}
+	}
+	return values
+}
+
+var fuzzStrings = []string{
+	"", "a", "hello world", "héllo ✓", strings.Repeat("x", 1024),
+	"../../etc/passwd", `'"<script>`, "%00", "null", "true", "-", " 1",
+}
+
+// candidates returns raw values for parameters of type t, boundary values,
+// values of other types and the enum values.
+func candidates(t reflect.Type, enum []string) []string {
+	values := append([]string{}, enum...)
+	if len(enum) > 0 {
+		values = append(values, "not-"+enum[0])
+	}
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := t.Bits()
+		min, max := int64(-1)<<(bits-1), int64(1)<<(bits-1)-1
+		values = append(values, "0", "1", "-1", "42",
+			strconv.FormatInt(min, 10), strconv.FormatInt(max, 10),
+			strconv.FormatInt(min, 10)+"0", strconv.FormatInt(max, 10)+"0",
+			"9223372036854775808", "1.5", "1e3", "0x10", "abc")
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		max := strconv.FormatUint(uint64(math.MaxUint64)>>(64-t.Bits()), 10)
+		values = append(values, "0", "1", "42", max, max+"0", "18446744073709551616", "-1", "1.5", "abc")
+	case reflect.Float32, reflect.Float64:
+		values = append(values, "0", "1.5", "-2.25", "1e308", "1e309", "-0", "NaN", "Inf", "abc")
+	}
+	return append(values, fuzzStrings...)
+}
+
+var malformedJSON = [][]byte{
+	nil, []byte("{"), []byte("["), []byte(`{"a":}`), []byte("nul"), []byte(`"unterminated`),
+	[]byte("{'a': 1}"), []byte("\x00\xff"), []byte("}"),
+}
+
+// wrongTypeJSON returns a JSON value t can't be decoded from, either another
+// kind of value or body with a field of the wrong type.
+func wrongTypeJSON(t reflect.Type, body []byte, rng *rand.Rand) ([]byte, bool) {
+	var fields map[string]any
+	if json.Unmarshal(body, &fields) == nil && len(fields) > 0 && rng.Intn(2) == 0 {
+		names := make([]string, 0, len(fields))
+		for name := range fields {
+			names = append(names, name)
+		}
+		name := names[rng.Intn(len(names))]
+		switch fields[name].(type) {
+		case string:
+			fields[name] = 12
+		case float64:
+			fields[name] = "12"
+		case bool:
+			fields[name] = "true"
+		case []any:
+			fields[name] = map[string]any{}
+		default:
+			fields[name] = []any{true}
+		}
+		wrong, err := json.Marshal(fields)
+		return wrong, err == nil
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch t.Kind() {
+	case reflect.String:
+		return []byte("12"), true
+	case reflect.Slice, reflect.Array:
+		return []byte(`{"a":1}`), true
+	case reflect.Interface:
+		return nil, false
+	}
+	return []byte(`"string"`), true
+}
+
+// randomJSON encodes a random value of type t.
+func randomJSON(t reflect.Type, rng *rand.Rand) []byte {
+	value := reflect.New(t).Elem()
+	randomValue(value, rng, 0)
+	data, err := json.Marshal(value.Interface())
+	if err != nil {
+		data, _ = json.Marshal(reflect.Zero(t).Interface())
+	}
+	return data
+}
+
+func randomValue(v reflect.Value, rng *rand.Rand, depth int) {
+	if depth > 4 || !v.CanSet() {
+		return
+	}
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(rng.Intn(2) == 0)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		bits := v.Type().Bits()
+		values := []int64{0, 1, -1, int64(-1) << (bits - 1), int64(1)<<(bits-1) - 1, rng.Int63n(1000)}
+		v.SetInt(values[rng.Intn(len(values))])
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		values := []uint64{0, 1, uint64(math.MaxUint64) >> (64 - v.Type().Bits()), uint64(rng.Int63n(1000))}
+		v.SetUint(values[rng.Intn(len(values))])
+	case reflect.Float32, reflect.Float64:
+		values := []float64{0, -1.5, 1e300, rng.Float64() * 1000}
+		if v.Kind() == reflect.Float32 {
+			values[2] = math.MaxFloat32
+		}
+		v.SetFloat(values[rng.Intn(len(values))])
+	case reflect.String:
+		v.SetString(fuzzStrings[rng.Intn(len(fuzzStrings))])
+	case reflect.Pointer:
+		if rng.Intn(3) > 0 {
+			v.Set(reflect.New(v.Type().Elem()))
+			randomValue(v.Elem(), rng, depth+1)
+		}
+	case reflect.Slice:
+		n := rng.Intn(4)
+		v.Set(reflect.MakeSlice(v.Type(), n, n))
+		for i := 0; i < n; i++ {
+			randomValue(v.Index(i), rng, depth+1)
+		}
+	case reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			randomValue(v.Index(i), rng, depth+1)
+		}
+	case reflect.Map:
+		v.Set(reflect.MakeMap(v.Type()))
+		for i := rng.Intn(3); i > 0; i-- {
+			key, value := reflect.New(v.Type().Key()).Elem(), reflect.New(v.Type().Elem()).Elem()
+			randomValue(key, rng, depth+1)
+			randomValue(value, rng, depth+1)
+			v.SetMapIndex(key, value)
+		}
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			v.Set(reflect.ValueOf(time.Unix(rng.Int63n(1<<32), 0).UTC()))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			randomValue(v.Field(i), rng, depth+1)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package fausttest_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/fausttest"
+	"github.com/nokusukun/faust/param"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+type item struct {
+	Name  string `json:"name"`
+	Count int    `json:"count"`
+}
+
+func TestFuzz(t *testing.T) {
+	api := faust.New()
+	api.Get("/items/{id:[0-9]+}", func(e *faust.Endpoint) http.HandlerFunc {
+		id := param.Path[int](e, "id")
+		sort := param.Query[string](e, "sort").Optional().Enum("name", "count")
+		limit := param.Query[int](e, "limit")
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprint(w, id.Value(r), sort.Value(r), limit.Value(r))
+		}
+	})
+	api.Post("/items", func(e *faust.Endpoint) http.HandlerFunc {
+		body := param.Json[item](e, "item")
+		return func(w http.ResponseWriter, r *http.Request) {
+			json.NewEncoder(w).Encode(body.Value(r))
+		}
+	})
+	fausttest.Fuzz(t, api, fausttest.FuzzOptions{Seed: 1})
+}
+
+// brokenAPI has an endpoint that always panics, its subrouter counts the
+// panics it reports.
+func brokenAPI(reported *int64) *faust.API {
+	api := faust.New()
+	admin := api.Subrouter("/admin")
+	admin.OnPanic = func(r *http.Request, err *faust.PanicError) {
+		atomic.AddInt64(reported, 1)
+	}
+	admin.Get("/crash", func(e *faust.Endpoint) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			panic("crash")
+		}
+	})
+	api.Get("/fail", func(e *faust.Endpoint) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	})
+	return api
+}
+
+// TestFuzzReportsFailures fuzzes brokenAPI in a child process, since the
+// failures it reports fail the test that runs it.
+func TestFuzzReportsFailures(t *testing.T) {
+	if os.Getenv("FAUSTTEST_FUZZ_BROKEN") == "1" {
+		var reported int64
+		api := brokenAPI(&reported)
+		t.Cleanup(func() {
+			fmt.Printf("OnPanic was called %d times, the API's OnPanic is set: %v\n", reported, api.OnPanic != nil)
+		})
+		// fuzzers of the same API run side by side, each sees its own panics
+		for i := 0; i < 4; i++ {
+			t.Run(fmt.Sprint(i), func(t *testing.T) {
+				t.Parallel()
+				fausttest.Fuzz(t, api, fausttest.FuzzOptions{Seed: 7})
+			})
+		}
+		return
+	}
+	if testing.Short() {
+		t.Skip("runs the test binary")
+	}
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFuzzReportsFailures$", "-test.v")
+	cmd.Env = append(os.Environ(), "FAUSTTEST_FUZZ_BROKEN=1")
+	out, err := cmd.CombinedOutput()
+	if err == nil {
+		t.Fatalf("fuzzing the broken API passed:\n%s", out)
+	}
+	output := string(out)
+	for want, count := range map[string]int{
+		"fausttest: GET /admin/crash panicked: crash":                  20,
+		"fausttest: GET /fail got 502":                                 20,
+		"fausttest: reproduce with FuzzOptions{Seed: 7}":               8,
+		"OnPanic was called 20 times, the API's OnPanic is set: false": 1,
+	} {
+		if got := strings.Count(output, want); got != count {
+			t.Errorf("got %q %d times, want %d", want, got, count)
+		}
+	}
+	if t.Failed() {
+		t.Logf("output:\n%s", output)
+	}
+}
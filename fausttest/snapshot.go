@@ -0,0 +1,220 @@
+// Package fausttest helps testing faust APIs.
+//
+// Importing it registers the -fausttest.update flag, which makes
+// AssertSpecSnapshot write its snapshots instead of comparing them:
+//
+//	go test ./myapi -fausttest.update
+//
+// A plain -update works as well, but only in test packages that define an
+// -update flag of their own, e.g. for golden files. Elsewhere go test fails
+// with "flag provided but not defined: -update".
+package fausttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/nokusukun/faust"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+// the flag is prefixed so it doesn't collide with the -update flag of golden
+// file tests, which updating honors as well
+var update = flag.Bool("fausttest.update", false, "update the spec snapshots of fausttest.AssertSpecSnapshot")
+
+// updating reports whether the tests run with -fausttest.update, or with an
+// -update flag defined by the test package.
+func updating() bool {
+	if *update {
+		return true
+	}
+	f := flag.Lookup("update")
+	if f == nil {
+		return false
+	}
+	getter, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	on, _ := getter.Get().(bool)
+	return on
+}
+
+// AssertSpecSnapshot compares the documentation of the built API, as served
+// at /docs.json, to the golden file at path and fails t with a diff when it
+// changed. Run the tests with -fausttest.update to write the file instead.
+func AssertSpecSnapshot(t testing.TB, api *faust.API, path string) {
+	t.Helper()
+	spec, err := Spec(api)
+	if err != nil {
+		t.Fatalf("fausttest: %v", err)
+	}
+	if updating() {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("fausttest: %v", err)
+		}
+		if err := os.WriteFile(path, spec, 0o644); err != nil {
+			t.Fatalf("fausttest: %v", err)
+		}
+		t.Logf("fausttest: updated %s", path)
+		return
+	}
+	golden, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Fatalf("fausttest: no spec snapshot at %s, run the test with -fausttest.update to create it", path)
+	}
+	if err != nil {
+		t.Fatalf("fausttest: %v", err)
+	}
+	if !bytes.Equal(golden, spec) {
+		t.Errorf("fausttest: the API no longer matches %s, run the test with -fausttest.update if the change is intended:\n%s",
+			path, diff(string(golden), string(spec)))
+	}
+}
+
+// Spec builds the API and returns its documentation as indented JSON with
+// sorted keys, endpoints sorted by path and method and subrouters by path,
+// so the same API always gives the same bytes.
+func Spec(api *faust.API) ([]byte, error) {
+	if err := api.Build(); err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(api)
+	if err != nil {
+		return nil, err
+	}
+	var doc any
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	sortDoc(doc)
+	// maps are encoded with sorted keys
+	spec, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	return append(spec, '\n'), nil
+}
+
+func sortDoc(value any) {
+	switch value := value.(type) {
+	case map[string]any:
+		for key, child := range value {
+			sortDoc(child)
+			list, ok := child.([]any)
+			if !ok || key != "endpoints" && key != "subroutes" {
+				continue
+			}
+			sort.SliceStable(list, func(i, j int) bool {
+				return sortKey(list[i]) < sortKey(list[j])
+			})
+		}
+	case []any:
+		for _, child := range value {
+			sortDoc(child)
+		}
+	}
+}
+
+func sortKey(value any) string {
+	fields, _ := value.(map[string]any)
+	path, _ := fields["path"].(string)
+	method, _ := fields["method"].(string)
+	return path + " " + method
+}
+
+// diff returns the lines that differ between a and b with a few lines of
+// context, prefixed with - and +.
+func diff(a, b string) string {
+	old, new := strings.Split(a, "\n"), strings.Split(b, "\n")
+	// only the middle that differs is compared line by line
+	prefix := 0
+	for prefix < len(old) && prefix < len(new) && old[prefix] == new[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(old)-prefix && suffix < len(new)-prefix && old[len(old)-1-suffix] == new[len(new)-1-suffix] {
+		suffix++
+	}
+	type line struct {
+		op   byte
+		text string
+		// old is the index of the line in a, or of the next one for
+		// added lines
+		old int
+	}
+	var lines []line
+	for i := 0; i < prefix; i++ {
+		lines = append(lines, line{' ', old[i], i})
+	}
+	oldMiddle, newMiddle := old[prefix:len(old)-suffix], new[prefix:len(new)-suffix]
+	if len(oldMiddle)*len(newMiddle) > 4_000_000 {
+		// too large to align, show it replaced
+		for i, text := range oldMiddle {
+			lines = append(lines, line{'-', text, prefix + i})
+		}
+		for _, text := range newMiddle {
+			lines = append(lines, line{'+', text, len(old) - suffix})
+		}
+	} else {
+		// longest common subsequence of the middle lines
+		lcs := make([][]int, len(oldMiddle)+1)
+		for i := range lcs {
+			lcs[i] = make([]int, len(newMiddle)+1)
+		}
+		for i := len(oldMiddle) - 1; i >= 0; i-- {
+			for j := len(newMiddle) - 1; j >= 0; j-- {
+				if oldMiddle[i] == newMiddle[j] {
+					lcs[i][j] = lcs[i+1][j+1] + 1
+				} else {
+					lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+				}
+			}
+		}
+		i, j := 0, 0
+		for i < len(oldMiddle) || j < len(newMiddle) {
+			switch {
+			case i < len(oldMiddle) && j < len(newMiddle) && oldMiddle[i] == newMiddle[j]:
+				lines = append(lines, line{' ', oldMiddle[i], prefix + i})
+				i++
+				j++
+			case j < len(newMiddle) && (i == len(oldMiddle) || lcs[i][j+1] >= lcs[i+1][j]):
+				lines = append(lines, line{'+', newMiddle[j], prefix + i})
+				j++
+			default:
+				lines = append(lines, line{'-', oldMiddle[i], prefix + i})
+				i++
+			}
+		}
+	}
+	for i := len(old) - suffix; i < len(old); i++ {
+		lines = append(lines, line{' ', old[i], i})
+	}
+
+	const context = 3
+	show := make([]bool, len(lines))
+	for i, l := range lines {
+		if l.op == ' ' {
+			continue
+		}
+		for k := max(0, i-context); k <= min(len(lines)-1, i+context); k++ {
+			show[k] = true
+		}
+	}
+	var out strings.Builder
+	for i, l := range lines {
+		if !show[i] {
+			continue
+		}
+		if i == 0 || !show[i-1] {
+			fmt.Fprintf(&out, "@@ line %d @@\n", l.old+1)
+		}
+		fmt.Fprintf(&out, "%c %s\n", l.op, l.text)
+	}
+	return out.String()
+}
@@ -0,0 +1,44 @@
+package fausttest_test
+
+import (
+	"flag"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/fausttest"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// a golden file flag of the test package, fausttest must not redefine it
+var updateGolden = flag.Bool("update", false, "update golden files")
+
+func snapshotAPI() *faust.API {
+	api := faust.New()
+	api.Get("/items", func(e *faust.Endpoint) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {}
+	})
+	return api
+}
+
+func TestAssertSpecSnapshotUpdate(t *testing.T) {
+	for _, name := range []string{"update", "fausttest.update"} {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "testdata", "api.json")
+			flag.Set(name, "true")
+			fausttest.AssertSpecSnapshot(t, snapshotAPI(), path)
+			flag.Set(name, "false")
+			golden, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if spec, _ := fausttest.Spec(snapshotAPI()); string(golden) != string(spec) {
+				t.Errorf("got snapshot %s, want %s", golden, spec)
+			}
+			fausttest.AssertSpecSnapshot(t, snapshotAPI(), path)
+		})
+	}
+	if *updateGolden {
+		t.Error("the -update flag was left on")
+	}
+}
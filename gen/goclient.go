@@ -0,0 +1,502 @@
+// Package gen generates API clients from the endpoints registered on a
+// faust.API and server stubs from OpenAPI documents.
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/internal/pathvars"
+	"go/format"
+	"go/token"
+	"io"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// typedParam is implemented by parameters the generators can type, e.g.
+// param.EndpointParam.
+type typedParam interface {
+	faust.ParamDescriber
+	ParamType() reflect.Type
+	ParamOptional() bool
+}
+
+// GoClient writes a Go client for the API as package pkg: a Client with one
+// method per endpoint, named after the endpoint, and an Error type for faust
+// error responses. Methods return the body type declared with param.Response
+// for the first success status, only an error for responses declared without
+// a body, and the *http.Response otherwise. Named types are imported from
+// their package, types of package main and internal packages, which the
+// client can't import, are copied into the client.
+func GoClient(w io.Writer, api *faust.API, pkg string) error {
+	if err := api.Build(); err != nil {
+		return err
+	}
+	g := &goGenerator{
+		imports: map[string]string{},
+		aliases: map[string]bool{},
+		locals:  map[reflect.Type]string{},
+		names:   map[string]bool{"Client": true, "Error": true, "New": true, "IsErrorType": true, "Decode": true},
+	}
+	var methods bytes.Buffer
+	for _, route := range api.Routes() {
+		g.method(&methods, route)
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by faust; DO NOT EDIT.\n\npackage %s\n\nimport (\n", pkg)
+	imports := []string{"bytes", "context", "encoding/json", "errors", "fmt", "io", "net/http", "net/url", "strings"}
+	for path := range g.imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+	for _, path := range imports {
+		if alias, ok := g.imports[path]; ok && alias != pathBase(path) {
+			fmt.Fprintf(&out, "\t%s %q\n", alias, path)
+		} else {
+			fmt.Fprintf(&out, "\t%q\n", path)
+		}
+	}
+	out.WriteString(")\n")
+	out.WriteString(goRuntime)
+	out.Write(g.decls.Bytes())
+	out.Write(methods.Bytes())
+
+	source, err := format.Source(out.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting generated client: %w", err)
+	}
+	_, err = w.Write(source)
+	return err
+}
+
+type goGenerator struct {
+	imports map[string]string
+	aliases map[string]bool
+	// locals are the types declared in the client, copied from packages that
+	// can't be imported
+	locals map[reflect.Type]string
+	names  map[string]bool
+	decls  bytes.Buffer
+}
+
+func (g *goGenerator) method(b *bytes.Buffer, route faust.Route) {
+	name := exportedName(route.Name)
+	if name == "" {
+		name = exportedName(strings.ToLower(route.Method) + " " + pathvars.Replace(route.Path, func(v pathvars.Variable) string {
+			return v.Name
+		}))
+	}
+	name = unique(name, g.names)
+
+	type argument struct {
+		in, name, arg, expr string
+		optional            bool
+	}
+	var args []argument
+	argNames := map[string]bool{"ctx": true, "c": true, "req": true, "resp": true, "result": true, "err": true, "path": true, "newRequest": true, "pathValue": true}
+	for name := range goRuntimeImports {
+		argNames[name] = true
+	}
+	untyped := false
+	for _, param := range route.Endpoint.Params {
+		typed, ok := param.(typedParam)
+		if !ok {
+			untyped = true
+			continue
+		}
+		in, paramName, _ := typed.ParamInfo()
+		a := argument{in: in, name: paramName, optional: typed.ParamOptional() && in != "path"}
+		a.arg = unique(unexportedName(paramName), argNames)
+		a.expr = g.typeExpr(typed.ParamType())
+		args = append(args, a)
+	}
+
+	fmt.Fprintf(b, "\n// %s calls %s %s.\n", name, route.Method, route.Path)
+	if description := route.Endpoint.EndpointInfo.Description; description != "" {
+		fmt.Fprintf(b, "//\n// %s\n", strings.ReplaceAll(description, "\n", "\n// "))
+	}
+	if untyped || route.Authorization != nil {
+		b.WriteString("//\n// Credentials, e.g. the Authorization header, are sent from Client.Header.\n")
+	}
+	fmt.Fprintf(b, "func (c *Client) %s(ctx context.Context", name)
+	for _, a := range args {
+		if a.optional {
+			fmt.Fprintf(b, ", %s *%s", a.arg, a.expr)
+		} else {
+			fmt.Fprintf(b, ", %s %s", a.arg, a.expr)
+		}
+	}
+	// zero returns from the method after a failed request
+	zero := "nil, err"
+	response, declared := successResponse(route.Endpoint.Responses)
+	switch {
+	case declared && response.Type != nil:
+		fmt.Fprintf(b, ") (result %s, err error) {\n", g.typeExpr(response.Type))
+		zero = "result, err"
+	case declared && response.Schema == nil:
+		b.WriteString(") error {\n")
+		zero = "err"
+	default:
+		b.WriteString(") (*http.Response, error) {\n")
+	}
+
+	b.WriteString("\tpath := ")
+	literals, variables := pathvars.Split(route.Path)
+	var parts []string
+	for i, variable := range variables {
+		parts = append(parts, strconv.Quote(literals[i]))
+		expr := strconv.Quote("")
+		for _, a := range args {
+			if a.in == "path" && a.name == variable.Name {
+				expr = fmt.Sprintf("pathValue(%s)", a.arg)
+			}
+		}
+		parts = append(parts, expr)
+	}
+	parts = append(parts, strconv.Quote(literals[len(literals)-1]))
+	var nonEmpty []string
+	for _, part := range parts {
+		if part != `""` {
+			nonEmpty = append(nonEmpty, part)
+		}
+	}
+	if len(nonEmpty) == 0 {
+		nonEmpty = []string{`""`}
+	}
+	b.WriteString(strings.Join(nonEmpty, " + ") + "\n")
+	fmt.Fprintf(b, "\treq := newRequest(%q, path)\n", route.Method)
+
+	for _, a := range args {
+		value := a.arg
+		if a.optional {
+			fmt.Fprintf(b, "\tif %s != nil {\n", a.arg)
+			value = "*" + a.arg
+		}
+		switch a.in {
+		case "query":
+			fmt.Fprintf(b, "\treq.query.Set(%q, fmt.Sprint(%s))\n", a.name, value)
+		case "header":
+			fmt.Fprintf(b, "\treq.header.Set(%q, fmt.Sprint(%s))\n", a.name, value)
+		case "form":
+			fmt.Fprintf(b, "\treq.form.Set(%q, fmt.Sprint(%s))\n", a.name, value)
+		case "body":
+			fmt.Fprintf(b, "\treq.text(fmt.Sprint(%s))\n", value)
+		case "jsonbody":
+			fmt.Fprintf(b, "\tif err := req.json(%s); err != nil {\n\t\treturn %s\n\t}\n", a.arg, zero)
+		}
+		if a.optional {
+			b.WriteString("\t}\n")
+		}
+	}
+	switch zero {
+	case "result, err":
+		b.WriteString("\tresp, err := c.do(ctx, req)\n\tif err != nil {\n\t\treturn result, err\n\t}\n")
+		b.WriteString("\terr = Decode(resp, &result)\n\treturn result, err\n}\n")
+	case "err":
+		b.WriteString("\tresp, err := c.do(ctx, req)\n\tif err != nil {\n\t\treturn err\n\t}\n")
+		b.WriteString("\treturn resp.Body.Close()\n}\n")
+	default:
+		b.WriteString("\treturn c.do(ctx, req)\n}\n")
+	}
+}
+
+// successResponse returns the declared response with the lowest 2xx status.
+func successResponse(responses []faust.ResponseInfo) (faust.ResponseInfo, bool) {
+	var success faust.ResponseInfo
+	found := false
+	for _, response := range responses {
+		if response.Status >= 200 && response.Status < 300 && (!found || response.Status < success.Status) {
+			success, found = response, true
+		}
+	}
+	return success, found
+}
+
+// typeExpr returns the Go expression of t in the client, importing or copying
+// named types as needed.
+func (g *goGenerator) typeExpr(t reflect.Type) string {
+	if t.Name() != "" {
+		if t.PkgPath() == "" {
+			return t.Name()
+		}
+		if importable(t.PkgPath()) && !strings.Contains(t.Name(), "[") {
+			return g.importPath(t.PkgPath()) + "." + t.Name()
+		}
+		return g.local(t)
+	}
+	return g.underlyingExpr(t)
+}
+
+// underlyingExpr spells out t without its name.
+func (g *goGenerator) underlyingExpr(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Pointer:
+		return "*" + g.typeExpr(t.Elem())
+	case reflect.Slice:
+		return "[]" + g.typeExpr(t.Elem())
+	case reflect.Array:
+		return fmt.Sprintf("[%d]%s", t.Len(), g.typeExpr(t.Elem()))
+	case reflect.Map:
+		return fmt.Sprintf("map[%s]%s", g.typeExpr(t.Key()), g.typeExpr(t.Elem()))
+	case reflect.Struct:
+		return g.structExpr(t)
+	case reflect.Interface:
+		return "any"
+	}
+	return t.Kind().String()
+}
+
+func (g *goGenerator) structExpr(t reflect.Type) string {
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		if field.Anonymous {
+			fmt.Fprintf(&b, "\t%s", g.typeExpr(field.Type))
+		} else {
+			fmt.Fprintf(&b, "\t%s %s", field.Name, g.typeExpr(field.Type))
+		}
+		if field.Tag != "" {
+			fmt.Fprintf(&b, " `%s`", field.Tag)
+		}
+		b.WriteString("\n")
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// local declares a copy of t in the client.
+func (g *goGenerator) local(t reflect.Type) string {
+	if name, ok := g.locals[t]; ok {
+		return name
+	}
+	name := unique(exportedName(t.Name()), g.names)
+	g.locals[t] = name
+	underlying := g.underlyingExpr(t)
+	fmt.Fprintf(&g.decls, "\n// %s is a copy of the server type %s.\ntype %s %s\n", name, t.String(), name, underlying)
+	return name
+}
+
+// importable reports whether the client can import the package at path.
+func importable(path string) bool {
+	if path == "main" || strings.HasSuffix(path, "_test") {
+		return false
+	}
+	for _, element := range strings.Split(path, "/") {
+		if element == "internal" {
+			return false
+		}
+	}
+	return true
+}
+
+func (g *goGenerator) importPath(path string) string {
+	if alias, ok := g.imports[path]; ok {
+		return alias
+	}
+	base := identifier(pathBase(path))
+	alias := base
+	for i := 2; g.aliases[alias] || token.IsKeyword(alias) || goRuntimeImports[alias]; i++ {
+		alias = fmt.Sprintf("%s%d", base, i)
+	}
+	g.aliases[alias] = true
+	g.imports[path] = alias
+	return alias
+}
+
+var goRuntimeImports = map[string]bool{
+	"bytes": true, "context": true, "json": true, "errors": true, "fmt": true,
+	"io": true, "http": true, "url": true, "strings": true,
+}
+
+func pathBase(path string) string {
+	return path[strings.LastIndex(path, "/")+1:]
+}
+
+func words(s string) []string {
+	return strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// exportedName turns s, e.g. "Get User Info" or "get-user", into GetUserInfo.
+func exportedName(s string) string {
+	var b strings.Builder
+	for _, word := range words(s) {
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	name := b.String()
+	if name != "" && unicode.IsDigit([]rune(name)[0]) {
+		name = "Op" + name
+	}
+	return name
+}
+
+// unexportedName turns s, e.g. "X-Request-ID", into xRequestID.
+func unexportedName(s string) string {
+	name := exportedName(s)
+	if name == "" {
+		return "value"
+	}
+	runes := []rune(name)
+	runes[0] = unicode.ToLower(runes[0])
+	name = string(runes)
+	if token.IsKeyword(name) {
+		name += "Value"
+	}
+	return name
+}
+
+func identifier(s string) string {
+	name := unexportedName(s)
+	return strings.ToLower(name)
+}
+
+func unique(name string, names map[string]bool) string {
+	candidate := name
+	for i := 2; names[candidate]; i++ {
+		candidate = fmt.Sprintf("%s%d", name, i)
+	}
+	names[candidate] = true
+	return candidate
+}
+
+const goRuntime = `
+// Client calls the API at BaseURL.
+type Client struct {
+	BaseURL    string
+	HTTPClient *http.Client
+	// Header is sent with every request, e.g. an Authorization header.
+	Header http.Header
+}
+
+func New(baseURL string) *Client {
+	return &Client{BaseURL: strings.TrimSuffix(baseURL, "/"), HTTPClient: http.DefaultClient, Header: http.Header{}}
+}
+
+// Error types of faust error responses.
+const (
+	ErrorValidation        = "validation_error"
+	ErrorUnauthenticated   = "unauthenticated"
+	ErrorForbidden         = "forbidden"
+	ErrorInvalidToken      = "invalid_token"
+	ErrorInsufficientScope = "insufficient_scope"
+	ErrorRateLimited       = "rate_limited"
+	ErrorOverloaded        = "overloaded"
+	ErrorTimeout           = "timeout"
+	ErrorInternal          = "internal_error"
+)
+
+// Error is returned for every response with a 4xx or 5xx status.
+type Error struct {
+	Status    int         ` + "`json:\"-\"`" + `
+	Header    http.Header ` + "`json:\"-\"`" + `
+	Message   string      ` + "`json:\"error\"`" + `
+	Type      string      ` + "`json:\"type\"`" + `
+	RequestID string      ` + "`json:\"request_id,omitempty\"`" + `
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%d %s: %s", e.Status, e.Type, e.Message)
+}
+
+// IsErrorType reports whether err is an *Error of the given type, e.g.
+// ErrorValidation.
+func IsErrorType(err error, errType string) bool {
+	var e *Error
+	return errors.As(err, &e) && e.Type == errType
+}
+
+type request struct {
+	method      string
+	path        string
+	query       url.Values
+	header      http.Header
+	form        url.Values
+	body        io.Reader
+	contentType string
+}
+
+func newRequest(method, path string) *request {
+	return &request{method: method, path: path, query: url.Values{}, header: http.Header{}, form: url.Values{}}
+}
+
+func (r *request) json(v any) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	r.body = bytes.NewReader(data)
+	r.contentType = "application/json"
+	return nil
+}
+
+func (r *request) text(s string) {
+	r.body = strings.NewReader(s)
+	r.contentType = "text/plain"
+}
+
+func pathValue(v any) string {
+	return url.PathEscape(fmt.Sprint(v))
+}
+
+// do sends the request, error responses are returned as *Error.
+func (c *Client) do(ctx context.Context, r *request) (*http.Response, error) {
+	if r.body == nil && len(r.form) > 0 {
+		r.body = strings.NewReader(r.form.Encode())
+		r.contentType = "application/x-www-form-urlencoded"
+	}
+	target := c.BaseURL + r.path
+	if len(r.query) > 0 {
+		target += "?" + r.query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, target, r.body)
+	if err != nil {
+		return nil, err
+	}
+	for key, values := range c.Header {
+		req.Header[key] = append([]string{}, values...)
+	}
+	for key, values := range r.header {
+		req.Header[key] = values
+	}
+	if r.contentType != "" {
+		req.Header.Set("Content-Type", r.contentType)
+	}
+	httpClient := c.HTTPClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 400 {
+		return resp, nil
+	}
+	defer resp.Body.Close()
+	apiErr := &Error{Status: resp.StatusCode, Header: resp.Header}
+	body, _ := io.ReadAll(resp.Body)
+	if json.Unmarshal(body, apiErr) != nil || apiErr.Type == "" {
+		apiErr.Type = "unknown"
+		apiErr.Message = strings.TrimSpace(string(body))
+	}
+	return nil, apiErr
+}
+
+// Decode decodes the JSON body of resp into v and closes it.
+func Decode(resp *http.Response, v any) error {
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(v)
+}
+`
@@ -0,0 +1,194 @@
+package gen_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/gen"
+	"github.com/nokusukun/faust/jwt"
+	"github.com/nokusukun/faust/param"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+type Owner struct {
+	Name string `json:"name"`
+}
+
+type Pet struct {
+	ID         int             `json:"id"`
+	Name       string          `json:"name"`
+	Born       time.Time       `json:"born"`
+	Vaccinated jwt.NumericDate `json:"vaccinated"`
+	Owner      *Owner          `json:"owner,omitempty"`
+}
+
+func petsAPI() *faust.API {
+	api := faust.New()
+	api.Post("/pets", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Name("createPet")
+		body := param.Json[Pet](e, "pet")
+		param.Response[Pet](e, http.StatusCreated, "The new pet")
+		return func(w http.ResponseWriter, r *http.Request) {
+			pet := body.Value(r)
+			pet.ID = 7
+			pet.Owner = &Owner{Name: "Ann"}
+			w.WriteHeader(http.StatusCreated)
+			json.NewEncoder(w).Encode(pet)
+		}
+	})
+	api.Get("/pets/{id}", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Name("getPet")
+		id := param.Path[int](e, "id")
+		param.Response[Pet](e, http.StatusOK, "The pet")
+		return func(w http.ResponseWriter, r *http.Request) {
+			if id.Value(r) != 7 {
+				faust.WriteError(w, r, faust.NewError(http.StatusNotFound, "not_found", errors.New("no such pet")))
+				return
+			}
+			json.NewEncoder(w).Encode(Pet{ID: 7, Name: "Rex"})
+		}
+	})
+	api.Delete("/pets/{id}", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Name("deletePet")
+		param.Path[int](e, "id")
+		e.Returns(http.StatusNoContent, "Deleted", nil)
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	api.Get("/health", func(e *faust.Endpoint) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("ok"))
+		}
+	})
+	return api
+}
+
+// goRun runs the main package made of files in a module that requires this
+// repository, and returns its output.
+func goRun(t *testing.T, files map[string]string, args ...string) string {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("compiles generated code")
+	}
+	goBin, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("go is not installed")
+	}
+	repo, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum, err := os.ReadFile(filepath.Join(repo, "go.sum"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	files["go.mod"] = "module example.com/generated\n\ngo 1.21\n\nrequire github.com/nokusukun/faust v0.0.0\n\nreplace github.com/nokusukun/faust => " + repo + "\n"
+	files["go.sum"] = string(sum)
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	cmd := exec.Command(goBin, append([]string{"run", "."}, args...)...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), "GOFLAGS=-mod=mod", "GOPROXY=off", "GOTOOLCHAIN=local")
+	out, err := cmd.CombinedOutput()
+	if err != nil {
+		t.Fatalf("go run: %v\n%s", err, out)
+	}
+	return string(out)
+}
+
+func TestGoClient(t *testing.T) {
+	var client bytes.Buffer
+	if err := gen.GoClient(&client, petsAPI(), "client"); err != nil {
+		t.Fatal(err)
+	}
+	source := client.String()
+	for _, want := range []string{
+		"func (c *Client) CreatePet(ctx context.Context, pet Pet) (result Pet, err error)",
+		"func (c *Client) GetPet(ctx context.Context, id int) (result Pet, err error)",
+		"func (c *Client) DeletePet(ctx context.Context, id int) error",
+		"func (c *Client) GetHealth(ctx context.Context) (*http.Response, error)",
+		"type Pet struct",
+		"type Owner struct",
+		`"github.com/nokusukun/faust/jwt"`,
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("the client has no %q", want)
+		}
+	}
+	if strings.Contains(source, `faust/gen_test"`) {
+		t.Error("the client imports the package of its server types")
+	}
+
+	server := httptest.NewServer(petsAPI())
+	defer server.Close()
+	out := goRun(t, map[string]string{
+		"client/client.go": source,
+		"main.go": `package main
+
+import (
+	"context"
+	"example.com/generated/client"
+	"fmt"
+	"os"
+	"time"
+)
+
+func main() {
+	c := client.New(os.Args[1])
+	ctx := context.Background()
+	born := time.Date(2020, 1, 2, 0, 0, 0, 0, time.UTC)
+	created, err := c.CreatePet(ctx, client.Pet{Name: "Rex", Born: born})
+	fmt.Println(created.ID, created.Name, created.Born.Equal(born), created.Owner.Name, err)
+	pet, err := c.GetPet(ctx, 7)
+	fmt.Println(pet.Name, err)
+	_, err = c.GetPet(ctx, 1)
+	fmt.Println(client.IsErrorType(err, "not_found"))
+	fmt.Println(c.DeletePet(ctx, 7))
+	resp, err := c.GetHealth(ctx)
+	fmt.Println(resp.StatusCode, err)
+}
+`,
+	}, server.URL)
+	want := "7 Rex true Ann <nil>\nRex <nil>\ntrue\n<nil>\n200 <nil>\n"
+	if out != want {
+		t.Errorf("got output\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestGoClientPathPatterns(t *testing.T) {
+	api := faust.New()
+	api.Get("/codes/{code:[a-z]{3}}/items/{id:[0-9]+}", func(e *faust.Endpoint) http.HandlerFunc {
+		param.Path[string](e, "code")
+		param.Path[int](e, "id")
+		return func(w http.ResponseWriter, r *http.Request) {}
+	})
+	var client bytes.Buffer
+	if err := gen.GoClient(&client, api, "client"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"func (c *Client) GetCodesCodeItemsId(ctx context.Context, code string, id int) (*http.Response, error)",
+		`path := "/codes/" + pathValue(code) + "/items/" + pathValue(id)`,
+	} {
+		if !strings.Contains(client.String(), want) {
+			t.Errorf("the client has no %q:\n%s", want, client.String())
+		}
+	}
+}
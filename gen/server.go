@@ -0,0 +1,542 @@
+package gen
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/nokusukun/faust/internal/pathvars"
+	"github.com/nokusukun/faust/internal/sorted"
+	"github.com/nokusukun/faust/openapi"
+	"go/format"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Server writes Go code for package pkg that declares the operations of doc
+// as faust endpoints: struct types for the schemas, a Register function
+// adding the endpoints to an API and a handler per operation that declares
+// its parameters and responses and answers 501 until it is implemented.
+func Server(w io.Writer, doc *openapi.Document, pkg string) error {
+	g := &serverGenerator{
+		doc:        doc,
+		types:      map[string]bool{"Register": true},
+		components: map[string]string{},
+		handlers: map[string]bool{
+			"errors": true, "faust": true, "http": true, "param": true, "time": true,
+		},
+	}
+	if doc.Components != nil {
+		for _, name := range sorted.Keys(doc.Components.Schemas) {
+			g.components[name] = unique(exportedName(name), g.types)
+			if g.components[name] == "" {
+				g.components[name] = unique("Schema", g.types)
+			}
+		}
+		for _, name := range sorted.Keys(doc.Components.Schemas) {
+			g.declare(g.components[name], doc.Components.Schemas[name])
+		}
+	}
+
+	var register, handlers bytes.Buffer
+	register.WriteString("\n// Register adds the endpoints of the API to api.\nfunc Register(api *faust.API) {\n")
+	for _, path := range sorted.Keys(doc.Paths) {
+		operations := doc.Paths[path].Operations()
+		for _, method := range methodOrder {
+			operation, ok := operations[method]
+			if !ok {
+				continue
+			}
+			name := g.handler(&handlers, method, path, operation)
+			switch method {
+			case "GET", "POST", "PUT", "PATCH", "DELETE":
+				fmt.Fprintf(&register, "\tapi.%s%s(%q, %s)\n", method[:1], strings.ToLower(method[1:]), path, name)
+			default:
+				fmt.Fprintf(&register, "\tapi.Method(%q, %q, %s)\n", method, path, name)
+			}
+		}
+	}
+	register.WriteString("}\n")
+
+	var out bytes.Buffer
+	source := strings.TrimSpace(doc.Info.Title + " " + doc.Info.Version)
+	if source == "" {
+		source = "an OpenAPI document"
+	}
+	fmt.Fprintf(&out, "// Generated by faust-gen from %s, the handlers are stubs to implement.\n\npackage %s\n\nimport (\n", source, pkg)
+	imports := []string{"errors", "github.com/nokusukun/faust", "net/http"}
+	if bytes.Contains(handlers.Bytes(), []byte("param.")) {
+		imports = append(imports, "github.com/nokusukun/faust/param")
+	}
+	if g.usesTime {
+		imports = append(imports, "time")
+	}
+	sort.Strings(imports)
+	for _, path := range imports {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString(")\n")
+	out.Write(g.decls.Bytes())
+	out.Write(register.Bytes())
+	out.Write(handlers.Bytes())
+
+	formatted, err := format.Source(out.Bytes())
+	if err != nil {
+		return fmt.Errorf("formatting generated server: %w", err)
+	}
+	_, err = w.Write(formatted)
+	return err
+}
+
+type serverGenerator struct {
+	doc *openapi.Document
+	// components are the Go type names of the component schemas
+	components map[string]string
+	types      map[string]bool
+	handlers   map[string]bool
+	decls      bytes.Buffer
+	usesTime   bool
+}
+
+func (g *serverGenerator) declare(name string, schema *openapi.Schema) {
+	var decl bytes.Buffer
+	if schema.Description != "" {
+		fmt.Fprintf(&decl, "\n// %s", strings.ReplaceAll(schema.Description, "\n", "\n// "))
+	}
+	if isObject(schema) && len(schema.Properties) > 0 {
+		fmt.Fprintf(&decl, "\ntype %s %s\n", name, g.structType(name, schema))
+	} else {
+		fmt.Fprintf(&decl, "\ntype %s %s\n", name, g.goType(schema, name, true))
+	}
+	g.decls.Write(decl.Bytes())
+}
+
+func (g *serverGenerator) structType(name string, schema *openapi.Schema) string {
+	required := map[string]bool{}
+	for _, property := range schema.Required {
+		required[property] = true
+	}
+	fields := map[string]bool{}
+	var b strings.Builder
+	b.WriteString("struct {\n")
+	for _, property := range sorted.Keys(schema.Properties) {
+		field := schema.Properties[property]
+		fieldName := exportedName(property)
+		if fieldName == "" {
+			fieldName = "Field"
+		}
+		fieldName = unique(fieldName, fields)
+		if field.Description != "" {
+			fmt.Fprintf(&b, "\t// %s\n", strings.ReplaceAll(field.Description, "\n", "\n\t// "))
+		}
+		tag := property
+		if !required[property] {
+			tag += ",omitempty"
+		}
+		tags := fmt.Sprintf("json:%q", tag)
+		if enum := enumTag(field.Enum); enum != "" {
+			tags += fmt.Sprintf(" enum:%q", enum)
+		}
+		fmt.Fprintf(&b, "\t%s %s `%s`\n", fieldName, g.goType(field, name+fieldName, required[property]), tags)
+	}
+	b.WriteString("}")
+	return b.String()
+}
+
+// goType returns the Go type of schema, objects without a component schema
+// are declared as types named hint.
+func (g *serverGenerator) goType(schema *openapi.Schema, hint string, required bool) string {
+	if schema == nil {
+		return "any"
+	}
+	var t string
+	pointer := schema.Nullable
+	switch {
+	case schema.Ref != "":
+		name := schema.Ref[strings.LastIndex(schema.Ref, "/")+1:]
+		t = g.components[name]
+		if t == "" {
+			return "any"
+		}
+		if component := g.doc.Components.Schemas[name]; component != nil && isObject(component) {
+			pointer = pointer || !required
+		}
+	case schema.Type == "string" && schema.Format == "date-time":
+		g.usesTime = true
+		t = "time.Time"
+	case schema.Type == "string":
+		t = "string"
+	case schema.Type == "integer":
+		t = integerType(schema.Format)
+	case schema.Type == "number":
+		t = "float64"
+		if schema.Format == "float" {
+			t = "float32"
+		}
+	case schema.Type == "boolean":
+		t = "bool"
+	case schema.Type == "array":
+		return "[]" + g.goType(schema.Items, hint+"Item", true)
+	case isObject(schema) && len(schema.Properties) > 0:
+		t = unique(hint, g.types)
+		g.declare(t, &openapi.Schema{Type: "object", Properties: schema.Properties, Required: schema.Required})
+		pointer = pointer || !required
+	case isObject(schema) && schema.AdditionalProperties != nil:
+		return "map[string]" + g.goType(schema.AdditionalProperties, hint+"Value", true)
+	case isObject(schema):
+		return "map[string]any"
+	default:
+		return "any"
+	}
+	if pointer {
+		return "*" + t
+	}
+	return t
+}
+
+func (g *serverGenerator) handler(b *bytes.Buffer, method, path string, operation *openapi.Operation) string {
+	id := operation.OperationID
+	if id == "" {
+		id = strings.ToLower(method) + " " + pathvars.Replace(path, func(v pathvars.Variable) string {
+			return v.Name
+		})
+	}
+	name := unique(unexportedName(id), g.handlers)
+	typeHint := exportedName(name)
+	locals := map[string]bool{"e": true, "w": true, "r": true, name: true}
+	for reserved := range g.handlers {
+		locals[reserved] = true
+	}
+	var declarations, values []string
+	declare := func(format string, args ...any) {
+		declarations = append(declarations, fmt.Sprintf(format, args...))
+	}
+	value := func(paramName string) string {
+		local := unique(unexportedName(paramName), locals)
+		values = append(values, local+".Value(r)")
+		return local
+	}
+
+	endpoint := "e"
+	if operation.OperationID != "" {
+		endpoint += fmt.Sprintf(".Name(%q)", operation.OperationID)
+	}
+	description := operation.Summary
+	if operation.Description != "" && operation.Description != description {
+		description = strings.TrimSpace(description + "\n\n" + operation.Description)
+	}
+	if description != "" {
+		endpoint += fmt.Sprintf(".Description(%q)", description)
+	}
+	if len(operation.Tags) > 0 {
+		endpoint += fmt.Sprintf(".Tags(%s)", quoteAll(operation.Tags))
+	}
+	if endpoint != "e" {
+		declare("%s", endpoint)
+	}
+	security := operation.Security
+	if security == nil {
+		security = g.doc.Security
+	}
+	for _, line := range securityDeclarations(security) {
+		declare("%s", line)
+	}
+
+	declared := map[string]bool{}
+	for _, reference := range operation.Parameters {
+		parameter := g.parameter(reference)
+		if parameter == nil {
+			declare("// TODO: declare the parameter %s, it can't be resolved", reference.Ref)
+			continue
+		}
+		constructor := map[string]string{"path": "Path", "query": "Query", "header": "Header"}[parameter.In]
+		if constructor == "" {
+			declare("// TODO: %s parameter %q is not supported", parameter.In, parameter.Name)
+			continue
+		}
+		if parameter.In == "path" {
+			declared[parameter.Name] = true
+		}
+		t, note := paramType(parameter.Schema)
+		call := fmt.Sprintf("%s := param.%s[%s](e, %q)", value(parameter.Name), constructor, t, parameter.Name)
+		if parameter.Description != "" {
+			call += fmt.Sprintf(".Description(%q)", parameter.Description)
+		}
+		if parameter.Schema != nil {
+			if enum := enumLiterals(parameter.Schema.Enum, t); enum != "" {
+				call += ".Enum(" + enum + ")"
+			}
+		}
+		if !parameter.Required && parameter.In != "path" {
+			call += ".Optional()"
+		}
+		declare("%s%s", call, note)
+	}
+	// path variables the spec doesn't declare are read as strings
+	for _, name := range pathvars.Names(path) {
+		if !declared[name] {
+			declare("%s := param.Path[string](e, %q)", value(name), name)
+		}
+	}
+
+	if body := g.requestBody(operation.RequestBody); body != nil {
+		optional := ""
+		if !body.Required {
+			optional = ".Optional()"
+		}
+		switch {
+		case body.Content["application/json"] != nil:
+			schema := body.Content["application/json"].Schema
+			if t, ok := g.structBody(schema, typeHint+"Request"); ok {
+				declare("%s := param.Json[%s](e, \"body\")%s", value("body"), t, optional)
+			} else {
+				declare("// TODO: read the JSON request body, a %s, param.Json only reads objects", g.goType(schema, typeHint+"Request", true))
+			}
+		case body.Content["application/x-www-form-urlencoded"] != nil, body.Content["multipart/form-data"] != nil:
+			media := body.Content["application/x-www-form-urlencoded"]
+			if media == nil {
+				media = body.Content["multipart/form-data"]
+			}
+			schema := g.resolve(media.Schema)
+			if schema == nil {
+				declare("// TODO: declare the form fields")
+				break
+			}
+			required := map[string]bool{}
+			for _, field := range schema.Required {
+				required[field] = true
+			}
+			for _, field := range sorted.Keys(schema.Properties) {
+				t, note := paramType(schema.Properties[field])
+				call := fmt.Sprintf("%s := param.Form[%s](e, %q)", value(field), t, field)
+				if !required[field] {
+					call += ".Optional()"
+				}
+				declare("%s%s", call, note)
+			}
+		case body.Content["text/plain"] != nil:
+			declare("%s := param.Body[string](e, \"body\")%s", value("body"), optional)
+		default:
+			declare("// TODO: read the %s request body", strings.Join(sorted.Keys(body.Content), " or "))
+		}
+	}
+
+	for _, status := range sorted.Keys(operation.Responses) {
+		code, err := strconv.Atoi(status)
+		if err != nil {
+			continue
+		}
+		response := g.response(operation.Responses[status])
+		if response == nil {
+			continue
+		}
+		if media := response.Content["application/json"]; media != nil && media.Schema != nil {
+			declare("param.Response[%s](e, %d, %q)", g.goType(media.Schema, typeHint+"Response", true), code, response.Description)
+		} else {
+			declare("e.Returns(%d, %q, nil)", code, response.Description)
+		}
+	}
+
+	fmt.Fprintf(b, "\n// %s handles %s %s.\nfunc %s(e *faust.Endpoint) http.HandlerFunc {\n", name, method, path, name)
+	for _, line := range declarations {
+		fmt.Fprintf(b, "\t%s\n", line)
+	}
+	b.WriteString("\n\treturn func(w http.ResponseWriter, r *http.Request) {\n")
+	fmt.Fprintf(b, "\t\t// TODO: implement %s\n", name)
+	if len(values) > 0 {
+		fmt.Fprintf(b, "\t\t%s = %s\n", strings.TrimSuffix(strings.Repeat("_, ", len(values)), ", "), strings.Join(values, ", "))
+	}
+	fmt.Fprintf(b, "\t\tfaust.WriteError(w, r, faust.NewError(http.StatusNotImplemented, \"not_implemented\", errors.New(%q)))\n\t}\n}\n", name+" is not implemented")
+	return name
+}
+
+// structBody returns the struct type of a JSON body schema, ok is false for
+// bodies param.Json can't read, like arrays and maps.
+func (g *serverGenerator) structBody(schema *openapi.Schema, hint string) (t string, ok bool) {
+	resolved := g.resolve(schema)
+	if resolved == nil || !isObject(resolved) || len(resolved.Properties) == 0 {
+		return "", false
+	}
+	// a nullable body is read as the struct, param.Json doesn't take pointers
+	return strings.TrimPrefix(g.goType(schema, hint, true), "*"), true
+}
+
+// parameter, requestBody, response and resolve follow references into the
+// components of the document, they return nil for references they can't
+// resolve.
+func (g *serverGenerator) parameter(parameter *openapi.Parameter) *openapi.Parameter {
+	if parameter == nil || parameter.Ref == "" {
+		return parameter
+	}
+	if g.doc.Components == nil {
+		return nil
+	}
+	return g.doc.Components.Parameters[refName(parameter.Ref)]
+}
+
+func (g *serverGenerator) requestBody(body *openapi.RequestBody) *openapi.RequestBody {
+	if body == nil || body.Ref == "" {
+		return body
+	}
+	if g.doc.Components == nil {
+		return nil
+	}
+	return g.doc.Components.RequestBodies[refName(body.Ref)]
+}
+
+func (g *serverGenerator) response(response *openapi.Response) *openapi.Response {
+	if response == nil || response.Ref == "" {
+		return response
+	}
+	if g.doc.Components == nil {
+		return nil
+	}
+	return g.doc.Components.Responses[refName(response.Ref)]
+}
+
+func (g *serverGenerator) resolve(schema *openapi.Schema) *openapi.Schema {
+	if schema == nil || schema.Ref == "" {
+		return schema
+	}
+	if g.doc.Components == nil {
+		return nil
+	}
+	return g.doc.Components.Schemas[refName(schema.Ref)]
+}
+
+func refName(ref string) string {
+	return ref[strings.LastIndex(ref, "/")+1:]
+}
+
+func isObject(schema *openapi.Schema) bool {
+	return schema.Type == "object" || schema.Type == "" && (schema.Properties != nil || schema.AdditionalProperties != nil)
+}
+
+func integerType(format string) string {
+	switch format {
+	case "int32":
+		return "int32"
+	case "int64":
+		return "int64"
+	}
+	return "int"
+}
+
+// paramType returns the type a parameter is read as, the parameters only
+// parse numbers and strings so other types are read as strings and noted.
+func paramType(schema *openapi.Schema) (t, note string) {
+	if schema == nil {
+		return "string", ""
+	}
+	switch schema.Type {
+	case "", "string":
+		return "string", ""
+	case "integer":
+		return integerType(schema.Format), ""
+	case "number":
+		if schema.Format == "float" {
+			return "float32", ""
+		}
+		return "float64", ""
+	}
+	return "string", fmt.Sprintf(" // TODO: a %s in the spec, parse the string", schema.Type)
+}
+
+// enumLiterals returns the values as Go literals of type t, or "" when one
+// doesn't fit.
+func enumLiterals(values []any, t string) string {
+	literals := make([]string, 0, len(values))
+	for _, value := range values {
+		switch v := value.(type) {
+		case string:
+			if t != "string" {
+				return ""
+			}
+			literals = append(literals, strconv.Quote(v))
+		case float64:
+			if t == "string" || strings.HasPrefix(t, "int") && v != float64(int64(v)) {
+				return ""
+			}
+			literals = append(literals, strconv.FormatFloat(v, 'f', -1, 64))
+		default:
+			return ""
+		}
+	}
+	return strings.Join(literals, ", ")
+}
+
+// enumTag returns the enum struct tag param reads struct enums from.
+func enumTag(values []any) string {
+	tags := make([]string, 0, len(values))
+	for _, value := range values {
+		tag := fmt.Sprint(value)
+		if value == nil || strings.Contains(tag, ",") {
+			return ""
+		}
+		tags = append(tags, tag)
+	}
+	return strings.Join(tags, ",")
+}
+
+func quoteAll(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = strconv.Quote(value)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// securityDeclarations requires what all security alternatives of an
+// operation ask for. faust can't express alternatives, so the endpoint never
+// requires more than the spec does and the rest is left as a TODO.
+func securityDeclarations(security []map[string][]string) []string {
+	if len(security) == 0 {
+		return nil
+	}
+	var common []string
+	for i, requirement := range security {
+		if len(requirement) == 0 {
+			return []string{fmt.Sprintf("// TODO: authentication is optional (security: %s), check the caller in the handler if needed", describeSecurity(security))}
+		}
+		have := map[string]bool{}
+		for _, scheme := range sorted.Keys(requirement) {
+			for _, scope := range requirement[scheme] {
+				if i == 0 && !have[scope] {
+					common = append(common, scope)
+				}
+				have[scope] = true
+			}
+		}
+		var kept []string
+		for _, scope := range common {
+			if have[scope] {
+				kept = append(kept, scope)
+			}
+		}
+		common = kept
+	}
+	require := fmt.Sprintf("e.RequireScopes(%s) // security: %s", quoteAll(common), describeSecurity(security))
+	if len(security) == 1 {
+		return []string{require}
+	}
+	return []string{"// TODO: require one of the security alternatives, only the scopes they share are required", require}
+}
+
+// describeSecurity formats security like "oauth(pets:read) or apiKey".
+func describeSecurity(security []map[string][]string) string {
+	alternatives := make([]string, len(security))
+	for i, requirement := range security {
+		var schemes []string
+		for _, scheme := range sorted.Keys(requirement) {
+			if scopes := requirement[scheme]; len(scopes) > 0 {
+				scheme += "(" + strings.Join(scopes, " ") + ")"
+			}
+			schemes = append(schemes, scheme)
+		}
+		if len(schemes) == 0 {
+			schemes = []string{"{}"}
+		}
+		alternatives[i] = strings.Join(schemes, " and ")
+	}
+	return strings.Join(alternatives, " or ")
+}
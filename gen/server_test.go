@@ -0,0 +1,293 @@
+package gen_test
+
+import (
+	"bytes"
+	"github.com/nokusukun/faust/gen"
+	"github.com/nokusukun/faust/openapi"
+	"strings"
+	"testing"
+)
+
+const petsSpec = `
+openapi: 3.0.3
+info:
+  title: Pets
+  version: 1.0.0
+paths:
+  /pets:
+    post:
+      operationId: createPets
+      requestBody:
+        required: true
+        content:
+          application/json:
+            schema:
+              type: array
+              items: {$ref: '#/components/schemas/Pet'}
+      responses:
+        "201":
+          description: The new pets
+          content:
+            application/json:
+              schema:
+                type: array
+                items: {$ref: '#/components/schemas/Pet'}
+    patch:
+      operationId: renamePets
+      requestBody:
+        content:
+          application/json:
+            schema:
+              type: object
+              additionalProperties: {type: string}
+      responses:
+        "204": {description: Renamed}
+  /pets/{id}:
+    put:
+      operationId: replacePet
+      parameters:
+        - {name: id, in: path, required: true, schema: {type: integer}}
+      requestBody:
+        content:
+          application/json:
+            schema:
+              $ref: '#/components/schemas/Pet'
+              nullable: true
+      responses:
+        "200":
+          description: The pet
+          content:
+            application/json:
+              schema: {$ref: '#/components/schemas/Pet'}
+components:
+  schemas:
+    Pet:
+      type: object
+      required: [name]
+      properties:
+        name: {type: string}
+        born: {type: string, format: date-time}
+`
+
+// generateServer writes the server of spec and runs it, out has a line with
+// the method, path and status of a request to each route.
+func generateServer(t *testing.T, spec string) (source, out string) {
+	t.Helper()
+	doc, err := openapi.Parse([]byte(spec))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := gen.Server(&b, doc, "api"); err != nil {
+		t.Fatal(err)
+	}
+	source = b.String()
+	out = goRun(t, map[string]string{
+		"api/endpoints.go": source,
+		"main.go": `package main
+
+import (
+	"example.com/generated/api"
+	"fmt"
+	"github.com/nokusukun/faust"
+	"net/http/httptest"
+	"strings"
+)
+
+func main() {
+	a := faust.New()
+	api.Register(a)
+	if err := a.Build(); err != nil {
+		panic(err)
+	}
+	for _, route := range a.Routes() {
+		path := route.Path
+		for _, p := range route.Params {
+			if p.In == "path" {
+				path = strings.ReplaceAll(path, "{"+p.Name+"}", "1")
+			}
+		}
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, httptest.NewRequest(route.Method, path, nil))
+		fmt.Println(route.Method, route.Path, w.Code)
+	}
+}
+`,
+	})
+	t.Logf("generated server:\n%s\nrequests:\n%s", source, out)
+	return source, out
+}
+
+func TestServerBodies(t *testing.T) {
+	source, out := generateServer(t, petsSpec)
+	for _, want := range []string{
+		"// TODO: read the JSON request body, a []Pet, param.Json only reads objects",
+		"// TODO: read the JSON request body, a map[string]string, param.Json only reads objects",
+		`body := param.Json[Pet](e, "body").Optional()`,
+		`param.Response[[]Pet](e, 201, "The new pets")`,
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("the server has no %q", want)
+		}
+	}
+	want := "PATCH /pets 501\nPOST /pets 501\nPUT /pets/{id} 501\n"
+	if out != want {
+		t.Errorf("got responses\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestServerPathItemParameters(t *testing.T) {
+	source, out := generateServer(t, `
+openapi: 3.0.3
+info: {title: Tenants, version: "1"}
+paths:
+  /items/{id}:
+    parameters:
+      - {name: id, in: path, required: true, schema: {type: integer}}
+      - {name: X-Tenant, in: header, required: true, schema: {type: string}}
+      - {name: verbose, in: query, schema: {type: string}}
+    get:
+      operationId: getItem
+      parameters:
+        - {name: verbose, in: query, required: true, schema: {type: integer}}
+      responses:
+        "200": {description: The item}
+`)
+	for _, want := range []string{
+		`id := param.Path[int](e, "id")`,
+		`xTenant := param.Header[string](e, "X-Tenant")`,
+		`verbose := param.Query[int](e, "verbose")`,
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("the server has no %q", want)
+		}
+	}
+	if strings.Count(source, `"verbose"`) != 1 {
+		t.Error("the operation parameter doesn't override the path item parameter")
+	}
+	// the required header and query parameters are missing
+	if want := "GET /items/{id} 422\n"; out != want {
+		t.Errorf("got responses\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestServerSecurity(t *testing.T) {
+	source, out := generateServer(t, `
+openapi: 3.0.3
+info: {title: Pets, version: "1"}
+security:
+  - bearer: []
+paths:
+  /pets:
+    get:
+      operationId: listPets
+      security:
+        - {}
+        - oauth: [pets:read]
+      responses:
+        "200": {description: The pets}
+    post:
+      operationId: createPet
+      responses:
+        "201": {description: Created}
+    delete:
+      operationId: deletePets
+      security:
+        - oauth: [pets:read, pets:write]
+        - apiKey: []
+          oauth: [pets:write]
+      responses:
+        "204": {description: Deleted}
+  /health:
+    get:
+      operationId: health
+      security: []
+      responses:
+        "200": {description: Healthy}
+components:
+  securitySchemes:
+    bearer: {type: http, scheme: bearer}
+    apiKey: {type: apiKey, in: header, name: X-API-Key}
+    oauth:
+      type: oauth2
+      flows:
+        clientCredentials:
+          tokenUrl: /token
+          scopes: {"pets:read": Read pets, "pets:write": Write pets}
+`)
+	for _, want := range []string{
+		"// TODO: authentication is optional (security: {} or oauth(pets:read)), check the caller in the handler if needed",
+		"e.RequireScopes() // security: bearer",
+		"// TODO: require one of the security alternatives, only the scopes they share are required",
+		`e.RequireScopes("pets:write") // security: oauth(pets:read pets:write) or apiKey and oauth(pets:write)`,
+	} {
+		if !strings.Contains(source, want) {
+			t.Errorf("the server has no %q", want)
+		}
+	}
+	if strings.Count(source, "RequireScopes") != 2 {
+		t.Error("got requirements for operations that allow anonymous callers")
+	}
+	want := "GET /health 501\nDELETE /pets 401\nGET /pets 501\nPOST /pets 401\n"
+	if out != want {
+		t.Errorf("got responses\n%s\nwant\n%s", out, want)
+	}
+}
+
+func TestServerForm(t *testing.T) {
+	doc, err := openapi.Parse([]byte(`
+openapi: 3.0.3
+info: {title: Pets, version: "1"}
+paths:
+  /pets:
+    post:
+      operationId: createPet
+      requestBody:
+        content:
+          application/x-www-form-urlencoded:
+            schema:
+              type: object
+              required: [name]
+              properties:
+                name: {type: string}
+                age: {type: integer}
+      responses:
+        "201": {description: Created}
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := gen.Server(&b, doc, "api"); err != nil {
+		t.Fatal(err)
+	}
+	out := goRun(t, map[string]string{
+		"api/endpoints.go": b.String(),
+		"main.go": `package main
+
+import (
+	"example.com/generated/api"
+	"fmt"
+	"github.com/nokusukun/faust"
+	"net/http/httptest"
+	"strings"
+)
+
+func main() {
+	a := faust.New()
+	api.Register(a)
+	for _, body := range []string{"name=rex&age=3", "age=3"} {
+		r := httptest.NewRequest("POST", "/pets", strings.NewReader(body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		a.ServeHTTP(w, r)
+		fmt.Println(body, w.Code)
+	}
+}
+`,
+	})
+	// the form is parsed, only the missing required field is rejected
+	if want := "name=rex&age=3 501\nage=3 422\n"; out != want {
+		t.Errorf("got responses\n%s\nwant\n%s\nfrom the server\n%s", out, want, b.String())
+	}
+}
@@ -0,0 +1,379 @@
+package gen
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"github.com/nokusukun/faust/internal/pathvars"
+	"github.com/nokusukun/faust/openapi"
+	"io"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	tsIdentifier = regexp.MustCompile(`^[A-Za-z_$][A-Za-z0-9_$]*$`)
+	methodOrder  = []string{"GET", "POST", "PUT", "PATCH", "DELETE", "HEAD", "OPTIONS", "TRACE"}
+)
+
+// TypeScript writes TypeScript interfaces for the component schemas of doc
+// and a fetch based Client with a typed method per operation. doc is e.g.
+// the result of faust.API.OpenAPI or a parsed openapi.json.
+func TypeScript(w io.Writer, doc *openapi.Document) error {
+	g := &tsGenerator{}
+	b := &g.out
+	b.WriteString("// Code generated by faust; DO NOT EDIT.\n")
+
+	if doc.Components != nil {
+		names := make([]string, 0, len(doc.Components.Schemas))
+		for name := range doc.Components.Schemas {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			schema := doc.Components.Schemas[name]
+			if schema.Description != "" {
+				fmt.Fprintf(b, "\n/** %s */", schema.Description)
+			}
+			if schema.Type == "object" && schema.Properties != nil {
+				fmt.Fprintf(b, "\nexport interface %s %s\n", tsTypeName(name), g.object(schema, ""))
+			} else {
+				fmt.Fprintf(b, "\nexport type %s = %s;\n", tsTypeName(name), g.typeOf(schema, ""))
+			}
+		}
+	}
+
+	b.WriteString(tsRuntime)
+
+	var params bytes.Buffer
+	b.WriteString("\nexport class Client extends BaseClient {")
+	paths := make([]string, 0, len(doc.Paths))
+	for path := range doc.Paths {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	names := map[string]bool{"request": true, "constructor": true}
+	for _, path := range paths {
+		operations := doc.Paths[path].Operations()
+		for _, method := range methodOrder {
+			if operation, ok := operations[method]; ok {
+				g.operation(&params, method, path, operation, names)
+			}
+		}
+	}
+	b.WriteString("}\n")
+	b.Write(params.Bytes())
+
+	_, err := w.Write(b.Bytes())
+	return err
+}
+
+type tsGenerator struct {
+	out bytes.Buffer
+}
+
+func (g *tsGenerator) operation(params *bytes.Buffer, method, path string, operation *openapi.Operation, names map[string]bool) {
+	id := operation.OperationID
+	if id == "" {
+		id = strings.ToLower(method) + " " + path
+	}
+	name := unique(unexportedName(id), names)
+	paramsType := exportedName(name) + "Params"
+
+	type field struct {
+		in, name, expr string
+		required       bool
+	}
+	var fields []field
+	for _, param := range operation.Parameters {
+		if param.Ref != "" {
+			continue
+		}
+		fields = append(fields, field{
+			in:       param.In,
+			name:     param.Name,
+			expr:     g.typeOf(param.Schema, "  "),
+			required: param.Required || param.In == "path",
+		})
+	}
+	bodyType, contentType := "", ""
+	if body := operation.RequestBody; body != nil {
+		for _, candidate := range []string{"application/json", "application/x-www-form-urlencoded", "text/plain"} {
+			if media, ok := body.Content[candidate]; ok {
+				contentType = candidate
+				bodyType = g.typeOf(media.Schema, "  ")
+				break
+			}
+		}
+		if contentType == "text/plain" {
+			bodyType = "string"
+		}
+		if contentType != "" {
+			fields = append(fields, field{in: "body", name: "body", expr: bodyType, required: body.Required})
+		}
+	}
+
+	returns := "unknown"
+	for _, status := range sortedStatuses(operation.Responses) {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		response := operation.Responses[status]
+		if media, ok := response.Content["application/json"]; ok && media.Schema != nil {
+			returns = g.typeOf(media.Schema, "  ")
+		} else if status == "204" {
+			returns = "void"
+		}
+		break
+	}
+
+	b := &g.out
+	fmt.Fprintf(b, "\n  /** %s %s", method, path)
+	if operation.Summary != "" {
+		fmt.Fprintf(b, "\n   *\n   * %s", strings.ReplaceAll(operation.Summary, "\n", "\n   * "))
+	}
+	b.WriteString(" */\n")
+	if len(fields) == 0 {
+		fmt.Fprintf(b, "  async %s(init?: RequestInit): Promise<%s> {\n", name, returns)
+		fmt.Fprintf(b, "    return this.request<%s>(%q, %q, {}, {}, undefined, undefined, init);\n  }\n", returns, method, path)
+		return
+	}
+
+	required := false
+	fmt.Fprintf(params, "\nexport interface %s {\n", paramsType)
+	for _, f := range fields {
+		optional := "?"
+		if f.required {
+			optional = ""
+			required = true
+		}
+		fmt.Fprintf(params, "  %s%s: %s;\n", tsProperty(f.name), optional, f.expr)
+	}
+	params.WriteString("}\n")
+
+	if required {
+		fmt.Fprintf(b, "  async %s(params: %s, init?: RequestInit): Promise<%s> {\n", name, paramsType, returns)
+	} else {
+		fmt.Fprintf(b, "  async %s(params: %s = {}, init?: RequestInit): Promise<%s> {\n", name, paramsType, returns)
+	}
+	url := pathvars.Replace(strings.ReplaceAll(path, "`", "\\`"), func(v pathvars.Variable) string {
+		return "${encodeURIComponent(String(params" + tsAccess(v.Name) + "))}"
+	})
+	var query, headers []string
+	for _, f := range fields {
+		switch f.in {
+		case "query":
+			query = append(query, fmt.Sprintf("%s: params%s", tsProperty(f.name), tsAccess(f.name)))
+		case "header":
+			headers = append(headers, fmt.Sprintf("%s: params%s", tsProperty(f.name), tsAccess(f.name)))
+		}
+	}
+	body, content := "undefined", "undefined"
+	switch contentType {
+	case "application/json":
+		body, content = "params.body === undefined ? undefined : JSON.stringify(params.body)", `"application/json"`
+	case "application/x-www-form-urlencoded":
+		body, content = "params.body === undefined ? undefined : formBody(params.body)", `"application/x-www-form-urlencoded"`
+	case "text/plain":
+		body, content = "params.body", `"text/plain"`
+	}
+	fmt.Fprintf(b, "    return this.request<%s>(%q, `%s`, {%s}, {%s}, %s, %s, init);\n  }\n",
+		returns, method, url, strings.Join(query, ", "), strings.Join(headers, ", "), body, content)
+}
+
+// typeOf returns the TypeScript type of schema, indent is the indentation of
+// the line the type starts on.
+func (g *tsGenerator) typeOf(schema *openapi.Schema, indent string) string {
+	if schema == nil {
+		return "unknown"
+	}
+	var t string
+	switch {
+	case schema.Ref != "":
+		t = tsTypeName(schema.Ref[strings.LastIndex(schema.Ref, "/")+1:])
+	case len(schema.Enum) > 0:
+		literals := make([]string, 0, len(schema.Enum))
+		for _, value := range schema.Enum {
+			literal, _ := json.Marshal(value)
+			literals = append(literals, string(literal))
+		}
+		t = strings.Join(literals, " | ")
+	case schema.Type == "string":
+		t = "string"
+	case schema.Type == "integer", schema.Type == "number":
+		t = "number"
+	case schema.Type == "boolean":
+		t = "boolean"
+	case schema.Type == "array":
+		item := g.typeOf(schema.Items, indent)
+		if strings.Contains(item, "|") {
+			item = "(" + item + ")"
+		}
+		t = item + "[]"
+	case schema.Type == "object" && len(schema.Properties) > 0:
+		t = g.object(schema, indent)
+	case schema.Type == "object" && schema.AdditionalProperties != nil:
+		t = "Record<string, " + g.typeOf(schema.AdditionalProperties, indent) + ">"
+	case schema.Type == "object":
+		t = "Record<string, unknown>"
+	default:
+		t = "unknown"
+	}
+	if schema.Nullable {
+		t += " | null"
+	}
+	return t
+}
+
+func (g *tsGenerator) object(schema *openapi.Schema, indent string) string {
+	required := map[string]bool{}
+	for _, name := range schema.Required {
+		required[name] = true
+	}
+	names := make([]string, 0, len(schema.Properties))
+	for name := range schema.Properties {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString("{\n")
+	for _, name := range names {
+		property := schema.Properties[name]
+		if property.Description != "" {
+			fmt.Fprintf(&b, "%s  /** %s */\n", indent, property.Description)
+		}
+		optional := "?"
+		if required[name] {
+			optional = ""
+		}
+		fmt.Fprintf(&b, "%s  %s%s: %s;\n", indent, tsProperty(name), optional, g.typeOf(property, indent+"  "))
+	}
+	b.WriteString(indent + "}")
+	return b.String()
+}
+
+func sortedStatuses(responses map[string]*openapi.Response) []string {
+	statuses := make([]string, 0, len(responses))
+	for status := range responses {
+		statuses = append(statuses, status)
+	}
+	sort.Strings(statuses)
+	return statuses
+}
+
+// tsReserved are the names of the client and of globals the client uses.
+var tsReserved = map[string]bool{
+	"ApiError": true, "BaseClient": true, "Client": true, "ClientOptions": true,
+	"Error": true, "Headers": true, "Promise": true, "Record": true, "RequestInit": true,
+	"Response": true, "URLSearchParams": true,
+}
+
+func tsTypeName(name string) string {
+	name = exportedName(name)
+	if name == "" {
+		return "Unnamed"
+	}
+	if tsReserved[name] {
+		return name + "Model"
+	}
+	return name
+}
+
+func tsProperty(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return name
+	}
+	quoted, _ := json.Marshal(name)
+	return string(quoted)
+}
+
+func tsAccess(name string) string {
+	if tsIdentifier.MatchString(name) {
+		return "." + name
+	}
+	return "[" + tsProperty(name) + "]"
+}
+
+const tsRuntime = `
+/** ApiError is thrown for every response with a 4xx or 5xx status. */
+export class ApiError extends Error {
+  constructor(
+    readonly status: number,
+    readonly type: string,
+    message: string,
+    readonly requestId?: string,
+    readonly headers?: Headers,
+  ) {
+    super(message);
+    this.name = "ApiError";
+  }
+}
+
+export interface ClientOptions {
+  baseUrl?: string;
+  /** Sent with every request, e.g. an Authorization header. */
+  headers?: Record<string, string>;
+  fetch?: typeof fetch;
+}
+
+function formBody(values: object): URLSearchParams {
+  const form = new URLSearchParams();
+  for (const [key, value] of Object.entries(values)) {
+    if (value !== undefined && value !== null) form.append(key, String(value));
+  }
+  return form;
+}
+
+/** BaseClient sends the requests of Client, it is exported for declaration files. */
+export class BaseClient {
+  protected readonly baseUrl: string;
+  protected readonly headers: Record<string, string>;
+  protected readonly fetch: typeof fetch;
+
+  constructor(options: ClientOptions = {}) {
+    this.baseUrl = (options.baseUrl ?? "").replace(/\/$/, "");
+    this.headers = options.headers ?? {};
+    this.fetch = options.fetch ?? globalThis.fetch.bind(globalThis);
+  }
+
+  protected async request<T>(
+    method: string,
+    path: string,
+    query: Record<string, unknown>,
+    headers: Record<string, unknown>,
+    body: BodyInit | undefined,
+    contentType: string | undefined,
+    init?: RequestInit,
+  ): Promise<T> {
+    const search = formBody(query).toString();
+    const requestHeaders = new Headers(this.headers);
+    for (const [key, value] of Object.entries(headers)) {
+      if (value !== undefined && value !== null) requestHeaders.set(key, String(value));
+    }
+    if (contentType && body !== undefined) requestHeaders.set("Content-Type", contentType);
+    const response = await this.fetch(this.baseUrl + path + (search ? "?" + search : ""), {
+      ...init,
+      method,
+      headers: requestHeaders,
+      body,
+    });
+    const text = await response.text();
+    if (!response.ok) {
+      let error = {error: text, type: "unknown", request_id: undefined as string | undefined};
+      try {
+        error = {...error, ...JSON.parse(text)};
+      } catch {
+        // not a faust error body
+      }
+      throw new ApiError(response.status, error.type, error.error, error.request_id, response.headers);
+    }
+    if (text === "") return undefined as T;
+    try {
+      return JSON.parse(text) as T;
+    } catch {
+      return text as T;
+    }
+  }
+}
+`
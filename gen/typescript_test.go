@@ -0,0 +1,62 @@
+package gen_test
+
+import (
+	"bytes"
+	"flag"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/gen"
+	"github.com/nokusukun/faust/param"
+	"net/http"
+	"os"
+	"path/filepath"
+	"regexp"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// TestTypeScript compares the client to a golden file, review the generated
+// code when updating it, no TypeScript compiler runs as part of the tests.
+func TestTypeScript(t *testing.T) {
+	api := petsAPI()
+	api.Get("/owners/{owner}/pets", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Name("listOwnerPets")
+		param.Path[string](e, "owner")
+		param.Query[string](e, "sort").Optional().Enum("name", "born")
+		param.Query[int](e, "page").Optional()
+		param.Header[string](e, "X-Tenant")
+		param.Response[[]Pet](e, http.StatusOK, "The pets of the owner")
+		return func(w http.ResponseWriter, r *http.Request) {}
+	})
+	doc, err := api.OpenAPI()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := gen.TypeScript(&b, doc); err != nil {
+		t.Fatal(err)
+	}
+	source := b.String()
+	// exported declarations can only use exported types under declaration: true
+	if unexported := regexp.MustCompile(`(?m)^(class|interface|type|enum) \w+`).FindAllString(source, -1); unexported != nil {
+		t.Errorf("unexported types %q", unexported)
+	}
+
+	path := filepath.Join("testdata", "client.ts.golden")
+	if *update {
+		if err := os.MkdirAll("testdata", 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, b.Bytes(), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v, run the test with -update to create it", err)
+	}
+	if source != string(want) {
+		t.Errorf("the client differs from %s, run the test with -update if the change is expected\n%s", path, source)
+	}
+}
@@ -1,8 +1,8 @@
 module github.com/nokusukun/faust
 
-go 1.18
+go 1.21
 
 require (
-	github.com/gorilla/mux v1.8.0 // indirect
-	github.com/orcaman/concurrent-map v1.0.0 // indirect
+	github.com/gorilla/mux v1.8.0
+	github.com/orcaman/concurrent-map v1.0.0
 )
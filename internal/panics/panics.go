@@ -0,0 +1,22 @@
+// Package panics lets fausttest see the panics faust recovers from the
+// requests it sends, without a hook in the public API.
+package panics
+
+import (
+	"context"
+)
+
+type observerKey struct{}
+
+// WithObserver returns a context whose requests pass the panics recovered
+// from endpoints, *faust.PanicError values, to observe.
+func WithObserver(ctx context.Context, observe func(error)) context.Context {
+	return context.WithValue(ctx, observerKey{}, observe)
+}
+
+// Observe passes err to the observer of ctx, if it has one.
+func Observe(ctx context.Context, err error) {
+	if observe, ok := ctx.Value(observerKey{}).(func(error)); ok {
+		observe(err)
+	}
+}
@@ -0,0 +1,76 @@
+// Package pathvars reads the variables of gorilla/mux path templates such as
+// "/users/{id:[0-9]+}". Patterns may contain braces of their own, e.g.
+// "{code:[a-z]{3}}".
+package pathvars
+
+import (
+	"strings"
+)
+
+type Variable struct {
+	Name string
+	// Pattern is the regular expression after the colon, empty for the
+	// default pattern.
+	Pattern string
+}
+
+// Split returns the text around the variables of template and the
+// variables, there is one more literal than variables. An unbalanced brace
+// is kept as text.
+func Split(template string) (literals []string, variables []Variable) {
+	start, open, level := 0, 0, 0
+	for i := 0; i < len(template); i++ {
+		switch template[i] {
+		case '{':
+			if level == 0 {
+				open = i
+			}
+			level++
+		case '}':
+			if level == 0 {
+				continue
+			}
+			level--
+			if level == 0 {
+				name, pattern, _ := strings.Cut(template[open+1:i], ":")
+				literals = append(literals, template[start:open])
+				variables = append(variables, Variable{Name: name, Pattern: pattern})
+				start = i + 1
+			}
+		}
+	}
+	return append(literals, template[start:]), variables
+}
+
+// Replace returns template with every variable replaced by fn.
+func Replace(template string, fn func(v Variable) string) string {
+	literals, variables := Split(template)
+	var b strings.Builder
+	for i, v := range variables {
+		b.WriteString(literals[i])
+		b.WriteString(fn(v))
+	}
+	b.WriteString(literals[len(literals)-1])
+	return b.String()
+}
+
+// Names returns the names of the variables of template in order.
+func Names(template string) []string {
+	_, variables := Split(template)
+	names := make([]string, len(variables))
+	for i, v := range variables {
+		names[i] = v.Name
+	}
+	return names
+}
+
+// Unnamed returns template without the names of its variables, templates
+// that only differ in variable names match the same paths.
+func Unnamed(template string) string {
+	return Replace(template, func(v Variable) string {
+		if v.Pattern == "" {
+			return "{}"
+		}
+		return "{:" + v.Pattern + "}"
+	})
+}
@@ -0,0 +1,40 @@
+package pathvars_test
+
+import (
+	"github.com/nokusukun/faust/internal/pathvars"
+	"reflect"
+	"testing"
+)
+
+func TestSplit(t *testing.T) {
+	tests := []struct {
+		template  string
+		literals  []string
+		variables []pathvars.Variable
+	}{
+		{"/users", []string{"/users"}, nil},
+		{"/users/{id}", []string{"/users/", ""}, []pathvars.Variable{{Name: "id"}}},
+		{"/{org}/users/{id:[0-9]+}.json", []string{"/", "/users/", ".json"}, []pathvars.Variable{{Name: "org"}, {Name: "id", Pattern: "[0-9]+"}}},
+		{"/codes/{code:[a-z]{3}}/x", []string{"/codes/", "/x"}, []pathvars.Variable{{Name: "code", Pattern: "[a-z]{3}"}}},
+		{"/stray}/{a", []string{"/stray}/{a"}, nil},
+	}
+	for _, test := range tests {
+		literals, variables := pathvars.Split(test.template)
+		if !reflect.DeepEqual(literals, test.literals) || !reflect.DeepEqual(variables, test.variables) {
+			t.Errorf("%s: got %q %+v, want %q %+v", test.template, literals, variables, test.literals, test.variables)
+		}
+	}
+}
+
+func TestReplace(t *testing.T) {
+	template := "/{org}/codes/{code:[a-z]{3}}"
+	if got := pathvars.Replace(template, func(v pathvars.Variable) string { return "<" + v.Name + ">" }); got != "/<org>/codes/<code>" {
+		t.Errorf("Replace: got %s", got)
+	}
+	if got := pathvars.Names(template); !reflect.DeepEqual(got, []string{"org", "code"}) {
+		t.Errorf("Names: got %q", got)
+	}
+	if got := pathvars.Unnamed(template); got != "/{}/codes/{:[a-z]{3}}" {
+		t.Errorf("Unnamed: got %s", got)
+	}
+}
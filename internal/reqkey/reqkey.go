@@ -0,0 +1,12 @@
+// Package reqkey keys the values parameters keep per request.
+package reqkey
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// Of returns the key of r, requests are told apart by their address.
+func Of(r *http.Request) string {
+	return fmt.Sprintf("%p", r)
+}
@@ -0,0 +1,23 @@
+// Package sorted lists the keys of maps in order, so that generated output
+// is deterministic.
+package sorted
+
+import (
+	"sort"
+)
+
+// Keys returns the keys of all maps, sorted and without duplicates.
+func Keys[V any](maps ...map[string]V) []string {
+	seen := map[string]bool{}
+	var keys []string
+	for _, m := range maps {
+		for key := range m {
+			if !seen[key] {
+				seen[key] = true
+				keys = append(keys, key)
+			}
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,223 @@
+package jwt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/internal/reqkey"
+	cmap "github.com/orcaman/concurrent-map"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+)
+
+type NumericDate struct {
+	time.Time
+}
+
+func (d *NumericDate) UnmarshalJSON(data []byte) error {
+	var n json.Number
+	if err := json.Unmarshal(data, &n); err != nil {
+		return err
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return err
+	}
+	if math.Abs(f) >= math.MaxInt64 {
+		return fmt.Errorf("numeric date %s out of range", n)
+	}
+	// nanoseconds since the epoch overflow int64 after the year 2262
+	sec, frac := math.Modf(f)
+	d.Time = time.Unix(int64(sec), int64(frac*float64(time.Second)))
+	return nil
+}
+
+func (d NumericDate) MarshalJSON() ([]byte, error) {
+	return json.Marshal(d.Unix())
+}
+
+// Audience accepts both the string and array forms of the aud claim.
+type Audience []string
+
+func (a *Audience) UnmarshalJSON(data []byte) error {
+	return unmarshalStrings(data, (*[]string)(a), false)
+}
+
+func (a Audience) Contains(aud string) bool {
+	for _, v := range a {
+		if v == aud {
+			return true
+		}
+	}
+	return false
+}
+
+// Scopes accepts both the space separated string and array forms of the
+// scope and scp claims.
+type Scopes []string
+
+func (s *Scopes) UnmarshalJSON(data []byte) error {
+	return unmarshalStrings(data, (*[]string)(s), true)
+}
+
+func unmarshalStrings(data []byte, out *[]string, split bool) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		if split {
+			*out = strings.Fields(single)
+		} else {
+			*out = []string{single}
+		}
+		return nil
+	}
+	return json.Unmarshal(data, out)
+}
+
+type RegisteredClaims struct {
+	Issuer    string       `json:"iss,omitempty"`
+	Subject   string       `json:"sub,omitempty"`
+	Audience  Audience     `json:"aud,omitempty"`
+	ExpiresAt *NumericDate `json:"exp,omitempty"`
+	NotBefore *NumericDate `json:"nbf,omitempty"`
+	IssuedAt  *NumericDate `json:"iat,omitempty"`
+	ID        string       `json:"jti,omitempty"`
+	Scope     Scopes       `json:"scope,omitempty"`
+	Scp       Scopes       `json:"scp,omitempty"`
+	Roles     Scopes       `json:"roles,omitempty"`
+}
+
+func (c RegisteredClaims) Scopes() []string {
+	return append(append([]string{}, c.Scope...), c.Scp...)
+}
+
+func (c RegisteredClaims) HasScope(scope string) bool {
+	for _, s := range c.Scopes() {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+type claimsInfo struct {
+	In          string   `json:"in"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	Scopes      []string `json:"scopes,omitempty"`
+	Schema      struct {
+		Type   string `json:"type"`
+		Format string `json:"format"`
+	} `json:"schema"`
+}
+
+type claimsValue[T any] struct {
+	token  *Token
+	claims T
+}
+
+// ClaimsParam is an endpoint parameter that verifies the bearer token of a
+// request and exposes its claims decoded into T.
+type ClaimsParam[T any] struct {
+	claimsInfo
+	verifier *Verifier
+	values   cmap.ConcurrentMap
+}
+
+// Claims declares a bearer token on the endpoint. Requests without a valid
+// token are rejected with 401, tokens missing any of the scopes with 403.
+func Claims[T any](e *faust.Endpoint, v *Verifier, scopes ...string) *ClaimsParam[T] {
+	param := &ClaimsParam[T]{
+		verifier: v,
+		values:   cmap.New(),
+	}
+	param.In = "header"
+	param.Name = "Authorization"
+	param.claimsInfo.Description = "Bearer JSON Web Token"
+	param.claimsInfo.Scopes = scopes
+	param.Schema.Type = "jwt"
+	param.Schema.Format = "bearer"
+	e.Params = append(e.Params, param)
+	return param
+}
+
+func (p *ClaimsParam[T]) Description(desc string) *ClaimsParam[T] {
+	p.claimsInfo.Description = desc
+	return p
+}
+
+func (p *ClaimsParam[T]) RequireScopes(scopes ...string) *ClaimsParam[T] {
+	p.claimsInfo.Scopes = append(p.claimsInfo.Scopes, scopes...)
+	return p
+}
+
+func (p *ClaimsParam[T]) ParamInfo() (in, name string, sensitive bool) {
+	return p.In, p.Name, true
+}
+
+func (p *ClaimsParam[T]) Use(r *http.Request) error {
+	raw, err := FromRequest(r)
+	if err != nil {
+		return unauthorized(err)
+	}
+	token, err := p.verifier.Verify(raw)
+	if err != nil {
+		return unauthorized(err)
+	}
+	for _, scope := range p.claimsInfo.Scopes {
+		if !token.Claims.HasScope(scope) {
+			fe := faust.NewError(http.StatusForbidden, "insufficient_scope", fmt.Errorf("missing required scope %s", scope))
+			fe.Header = http.Header{
+				"Www-Authenticate": {fmt.Sprintf(`Bearer error="insufficient_scope", scope="%s"`, strings.Join(p.claimsInfo.Scopes, " "))},
+			}
+			return fe
+		}
+	}
+	value := claimsValue[T]{token: token}
+	if err := json.Unmarshal(token.Payload, &value.claims); err != nil {
+		return unauthorized(fmt.Errorf("%w: %s", ErrMalformed, err.Error()))
+	}
+	p.values.Set(reqkey.Of(r), value)
+	return nil
+}
+
+func (p *ClaimsParam[T]) Dispose(r *http.Request) {
+	p.values.Remove(reqkey.Of(r))
+}
+
+func (p *ClaimsParam[T]) Value(r *http.Request) T {
+	val, _ := p.values.Get(reqkey.Of(r))
+	return val.(claimsValue[T]).claims
+}
+
+// Token returns the verified token of the request, including its registered
+// claims.
+func (p *ClaimsParam[T]) Token(r *http.Request) *Token {
+	val, _ := p.values.Get(reqkey.Of(r))
+	return val.(claimsValue[T]).token
+}
+
+// tokenErrors describe invalid tokens in WWW-Authenticate, the errors
+// themselves can quote the token, e.g. its alg.
+var tokenErrors = []error{
+	ErrMalformed, ErrAlgorithm, ErrSignature, ErrExpired, ErrNotYetValid, ErrIssuedAt, ErrIssuer, ErrAudience,
+}
+
+func unauthorized(err error) error {
+	fe := faust.NewError(http.StatusUnauthorized, "invalid_token", err)
+	challenge := `Bearer`
+	if err != ErrMissingToken {
+		description := "invalid token"
+		for _, tokenErr := range tokenErrors {
+			if errors.Is(err, tokenErr) {
+				description = tokenErr.Error()
+				break
+			}
+		}
+		challenge = fmt.Sprintf(`Bearer error="invalid_token", error_description="%s"`, description)
+	}
+	fe.Header = http.Header{"Www-Authenticate": {challenge}}
+	return fe
+}
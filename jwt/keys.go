@@ -0,0 +1,209 @@
+package jwt
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"sync"
+)
+
+const (
+	HS256 = "HS256"
+	RS256 = "RS256"
+	ES256 = "ES256"
+	EdDSA = "EdDSA"
+)
+
+// Key is a verification key. Key.Key holds a []byte secret for HS256, a
+// *rsa.PublicKey for RS256, an *ecdsa.PublicKey for ES256 or an
+// ed25519.PublicKey for EdDSA. Private keys are accepted and reduced to their
+// public half. An empty Algorithm is taken from the type of Key.Key.
+type Key struct {
+	ID        string
+	Algorithm string
+	Key       any
+}
+
+type KeySet struct {
+	lock sync.RWMutex
+	keys []Key
+}
+
+func NewKeySet(keys ...Key) *KeySet {
+	ks := &KeySet{}
+	for _, key := range keys {
+		ks.Add(key)
+	}
+	return ks
+}
+
+func (ks *KeySet) Add(key Key) *KeySet {
+	switch k := key.Key.(type) {
+	case string:
+		key.Key = []byte(k)
+	case *rsa.PrivateKey:
+		key.Key = &k.PublicKey
+	case *ecdsa.PrivateKey:
+		key.Key = &k.PublicKey
+	case ed25519.PrivateKey:
+		key.Key = k.Public()
+	}
+	if key.Algorithm == "" {
+		switch key.Key.(type) {
+		case []byte:
+			key.Algorithm = HS256
+		case *rsa.PublicKey:
+			key.Algorithm = RS256
+		case *ecdsa.PublicKey:
+			key.Algorithm = ES256
+		case ed25519.PublicKey:
+			key.Algorithm = EdDSA
+		default:
+			panic(fmt.Sprintf("jwt: unsupported key type %T", key.Key))
+		}
+	}
+	ks.lock.Lock()
+	ks.keys = append(ks.keys, key)
+	ks.lock.Unlock()
+	return ks
+}
+
+// candidates returns the keys usable for a token with the given kid and alg.
+// Tokens without a kid are tried against every key of the right algorithm.
+func (ks *KeySet) candidates(kid, alg string) []Key {
+	ks.lock.RLock()
+	defer ks.lock.RUnlock()
+	var keys []Key
+	for _, key := range ks.keys {
+		if key.Algorithm != alg {
+			continue
+		}
+		if kid != "" && key.ID != "" && key.ID != kid {
+			continue
+		}
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+	Use string `json:"use"`
+	K   string `json:"k"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// LoadJWKS reads a JSON Web Key Set from a local file.
+func LoadJWKS(path string) (*KeySet, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return ParseJWKS(data)
+}
+
+func ParseJWKS(data []byte) (*KeySet, error) {
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, fmt.Errorf("invalid jwks: %w", err)
+	}
+	ks := NewKeySet()
+	for i, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.key()
+		if err != nil {
+			return nil, fmt.Errorf("invalid jwks key %d (%s): %w", i, k.Kid, err)
+		}
+		ks.Add(key)
+	}
+	return ks, nil
+}
+
+func (k jwk) key() (Key, error) {
+	key := Key{ID: k.Kid, Algorithm: k.Alg}
+	switch k.Kty {
+	case "oct":
+		secret, err := b64(k.K)
+		if err != nil {
+			return key, err
+		}
+		key.Key = secret
+		if key.Algorithm == "" {
+			key.Algorithm = HS256
+		}
+	case "RSA":
+		n, err := b64(k.N)
+		if err != nil {
+			return key, err
+		}
+		e, err := b64(k.E)
+		if err != nil {
+			return key, err
+		}
+		key.Key = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+		if key.Algorithm == "" {
+			key.Algorithm = RS256
+		}
+	case "EC":
+		if k.Crv != "P-256" {
+			return key, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := b64(k.X)
+		if err != nil {
+			return key, err
+		}
+		y, err := b64(k.Y)
+		if err != nil {
+			return key, err
+		}
+		key.Key = &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}
+		if key.Algorithm == "" {
+			key.Algorithm = ES256
+		}
+	case "OKP":
+		if k.Crv != "Ed25519" {
+			return key, fmt.Errorf("unsupported curve %s", k.Crv)
+		}
+		x, err := b64(k.X)
+		if err != nil {
+			return key, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return key, fmt.Errorf("invalid Ed25519 key size %d", len(x))
+		}
+		key.Key = ed25519.PublicKey(x)
+		if key.Algorithm == "" {
+			key.Algorithm = EdDSA
+		}
+	default:
+		return key, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+	return key, nil
+}
+
+func b64(s string) ([]byte, error) {
+	return base64.RawURLEncoding.DecodeString(s)
+}
@@ -0,0 +1,234 @@
+package jwt
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/nokusukun/faust"
+	"math/big"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	ErrMissingToken = errors.New("missing bearer token")
+	ErrMalformed    = errors.New("malformed token")
+	ErrAlgorithm    = errors.New("unsupported token algorithm")
+	ErrSignature    = errors.New("invalid token signature")
+	ErrExpired      = errors.New("token is expired")
+	ErrNotYetValid  = errors.New("token is not valid yet")
+	ErrIssuedAt     = errors.New("token is issued in the future")
+	ErrIssuer       = errors.New("invalid token issuer")
+	ErrAudience     = errors.New("invalid token audience")
+)
+
+type Config struct {
+	Keys *KeySet
+	// Issuer and Audience are checked against iss and aud when set.
+	Issuer   string
+	Audience string
+	// Leeway is the allowed clock skew when checking exp, nbf and iat.
+	Leeway time.Duration
+	// Algorithms restricts the accepted algorithms, all supported algorithms
+	// are accepted when empty.
+	Algorithms []string
+	Now        func() time.Time
+}
+
+type Verifier struct {
+	Config
+}
+
+func New(config Config) *Verifier {
+	if config.Keys == nil {
+		config.Keys = NewKeySet()
+	}
+	if config.Now == nil {
+		config.Now = time.Now
+	}
+	return &Verifier{Config: config}
+}
+
+// Token is a verified token.
+type Token struct {
+	Raw     string
+	Header  Header
+	Claims  RegisteredClaims
+	Payload json.RawMessage
+}
+
+func (t *Token) Scopes() []string {
+	return t.Claims.Scopes()
+}
+
+func (t *Token) Roles() []string {
+	return t.Claims.Roles
+}
+
+type Header struct {
+	Algorithm string `json:"alg"`
+	KeyID     string `json:"kid,omitempty"`
+	Type      string `json:"typ,omitempty"`
+}
+
+// FromRequest extracts the token from the Authorization: Bearer header.
+func FromRequest(r *http.Request) (string, error) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "Bearer ") {
+		return "", ErrMissingToken
+	}
+	token := strings.TrimSpace(auth[7:])
+	if token == "" {
+		return "", ErrMissingToken
+	}
+	return token, nil
+}
+
+// Middleware verifies the bearer token of every request and places it on the
+// request as the faust.Principal, so that Endpoint.RequireScopes and
+// Endpoint.RequireRoles can be checked against it.
+func (v *Verifier) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		raw, err := FromRequest(r)
+		if err != nil {
+			faust.WriteError(w, r, unauthorized(err))
+			return
+		}
+		token, err := v.Verify(raw)
+		if err != nil {
+			faust.WriteError(w, r, unauthorized(err))
+			return
+		}
+		next.ServeHTTP(w, faust.WithPrincipal(r, token))
+	})
+}
+
+func (v *Verifier) Verify(raw string) (*Token, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, ErrMalformed
+	}
+	headerJson, err := b64(parts[0])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	payload, err := b64(parts[1])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+	signature, err := b64(parts[2])
+	if err != nil {
+		return nil, ErrMalformed
+	}
+
+	token := &Token{Raw: raw, Payload: payload}
+	if err := json.Unmarshal(headerJson, &token.Header); err != nil {
+		return nil, ErrMalformed
+	}
+	if !v.allowed(token.Header.Algorithm) {
+		return nil, fmt.Errorf("%w: %s", ErrAlgorithm, token.Header.Algorithm)
+	}
+
+	signed := []byte(raw[:len(parts[0])+1+len(parts[1])])
+	verified := false
+	for _, key := range v.Keys.candidates(token.Header.KeyID, token.Header.Algorithm) {
+		if verify(key, signed, signature) {
+			verified = true
+			break
+		}
+	}
+	if !verified {
+		return nil, ErrSignature
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+	if err := decoder.Decode(&token.Claims); err != nil {
+		return nil, ErrMalformed
+	}
+	if err := v.validate(token.Claims); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (v *Verifier) allowed(alg string) bool {
+	switch alg {
+	case HS256, RS256, ES256, EdDSA:
+	default:
+		return false
+	}
+	if len(v.Algorithms) == 0 {
+		return true
+	}
+	for _, a := range v.Algorithms {
+		if a == alg {
+			return true
+		}
+	}
+	return false
+}
+
+func (v *Verifier) validate(claims RegisteredClaims) error {
+	now := v.Now()
+	if claims.ExpiresAt != nil && !now.Before(claims.ExpiresAt.Add(v.Leeway)) {
+		return ErrExpired
+	}
+	if claims.NotBefore != nil && now.Add(v.Leeway).Before(claims.NotBefore.Time) {
+		return ErrNotYetValid
+	}
+	if claims.IssuedAt != nil && now.Add(v.Leeway).Before(claims.IssuedAt.Time) {
+		return ErrIssuedAt
+	}
+	if v.Issuer != "" && claims.Issuer != v.Issuer {
+		return ErrIssuer
+	}
+	if v.Audience != "" && !claims.Audience.Contains(v.Audience) {
+		return ErrAudience
+	}
+	return nil
+}
+
+func verify(key Key, signed, signature []byte) bool {
+	digest := sha256.Sum256(signed)
+	switch key.Algorithm {
+	case HS256:
+		secret, ok := key.Key.([]byte)
+		if !ok {
+			return false
+		}
+		mac := hmac.New(sha256.New, secret)
+		mac.Write(signed)
+		return hmac.Equal(mac.Sum(nil), signature)
+	case RS256:
+		pub, ok := key.Key.(*rsa.PublicKey)
+		if !ok {
+			return false
+		}
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], signature) == nil
+	case ES256:
+		pub, ok := key.Key.(*ecdsa.PublicKey)
+		if !ok || pub.Curve != elliptic.P256() || len(signature) != 64 {
+			return false
+		}
+		r := new(big.Int).SetBytes(signature[:32])
+		s := new(big.Int).SetBytes(signature[32:])
+		return ecdsa.Verify(pub, digest[:], r, s)
+	case EdDSA:
+		pub, ok := key.Key.(ed25519.PublicKey)
+		if !ok {
+			return false
+		}
+		return ed25519.Verify(pub, signed, signature)
+	}
+	return false
+}
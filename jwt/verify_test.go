@@ -0,0 +1,283 @@
+package jwt_test
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"github.com/nokusukun/faust/jwt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var now = time.Unix(1_700_000_000, 0)
+
+func b64(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// sign creates a token signed with the private key, or secret for HS256.
+func sign(t *testing.T, alg string, key any, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "typ": "JWT"})
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := b64(header) + "." + b64(payload)
+	digest := sha256.Sum256([]byte(signed))
+	var signature []byte
+	switch alg {
+	case jwt.HS256:
+		mac := hmac.New(sha256.New, key.([]byte))
+		mac.Write([]byte(signed))
+		signature = mac.Sum(nil)
+	case jwt.RS256:
+		signature, err = rsa.SignPKCS1v15(rand.Reader, key.(*rsa.PrivateKey), crypto.SHA256, digest[:])
+	case jwt.ES256:
+		r, s, signErr := ecdsa.Sign(rand.Reader, key.(*ecdsa.PrivateKey), digest[:])
+		size := (key.(*ecdsa.PrivateKey).Curve.Params().BitSize + 7) / 8
+		signature = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+		err = signErr
+	case jwt.EdDSA:
+		signature = ed25519.Sign(key.(ed25519.PrivateKey), []byte(signed))
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signed + "." + b64(signature)
+}
+
+type signer struct {
+	alg string
+	key any
+}
+
+func signers(t *testing.T) []signer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return []signer{
+		{jwt.HS256, []byte("secret")},
+		{jwt.RS256, rsaKey},
+		{jwt.ES256, ecKey},
+		{jwt.EdDSA, edKey},
+	}
+}
+
+func verifier(keys ...jwt.Key) *jwt.Verifier {
+	return jwt.New(jwt.Config{
+		Keys:   jwt.NewKeySet(keys...),
+		Leeway: time.Minute,
+		Now:    func() time.Time { return now },
+	})
+}
+
+func TestVerifySignatures(t *testing.T) {
+	all := signers(t)
+	for i, s := range all {
+		t.Run(s.alg, func(t *testing.T) {
+			v := verifier(jwt.Key{Algorithm: s.alg, Key: s.key})
+			token := sign(t, s.alg, s.key, map[string]any{"sub": "alice", "scope": "read write"})
+			verified, err := v.Verify(token)
+			if err != nil {
+				t.Fatalf("valid token rejected: %v", err)
+			}
+			if verified.Claims.Subject != "alice" || !verified.Claims.HasScope("write") {
+				t.Errorf("got claims %+v", verified.Claims)
+			}
+
+			parts := strings.Split(token, ".")
+			tampered := parts[0] + "." + b64([]byte(`{"sub":"mallory"}`)) + "." + parts[2]
+			if _, err := v.Verify(tampered); !errors.Is(err, jwt.ErrSignature) {
+				t.Errorf("tampered signature: got %v, want %v", err, jwt.ErrSignature)
+			}
+
+			other := all[(i+1)%len(all)]
+			forged := sign(t, other.alg, other.key, map[string]any{"sub": "alice"})
+			if _, err := v.Verify(forged); err == nil {
+				t.Errorf("token signed with a %s key accepted", other.alg)
+			}
+		})
+	}
+}
+
+func TestKeysWithoutAlgorithm(t *testing.T) {
+	for _, s := range signers(t) {
+		v := verifier(jwt.Key{ID: "k1", Key: s.key})
+		if _, err := v.Verify(sign(t, s.alg, s.key, map[string]any{"sub": "alice"})); err != nil {
+			t.Errorf("%s: key added without an algorithm: %v", s.alg, err)
+		}
+	}
+	defer func() {
+		if recover() == nil {
+			t.Error("a key of unknown type was accepted")
+		}
+	}()
+	jwt.NewKeySet(jwt.Key{Key: 42})
+}
+
+func TestMiddlewareChallenge(t *testing.T) {
+	handler := verifier(jwt.Key{Algorithm: jwt.HS256, Key: []byte("secret")}).Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	header := b64([]byte(`{"alg":"x\", error=\"injected","typ":"JWT"}`))
+	tests := map[string]string{
+		"":                           `Bearer`,
+		"Bearer " + header + ".e30.": `Bearer error="invalid_token", error_description="unsupported token algorithm"`,
+		"Bearer not-a-token":         `Bearer error="invalid_token", error_description="malformed token"`,
+	}
+	for authorization, want := range tests {
+		r := httptest.NewRequest("GET", "/", nil)
+		if authorization != "" {
+			r.Header.Set("Authorization", authorization)
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, r)
+		if got := w.Header().Get("WWW-Authenticate"); w.Code != http.StatusUnauthorized || got != want {
+			t.Errorf("%q: got %d with challenge %s, want %s", authorization, w.Code, got, want)
+		}
+	}
+}
+
+func TestVerifyAlgorithmConfusion(t *testing.T) {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	v := verifier(jwt.Key{Algorithm: jwt.RS256, Key: rsaKey})
+	// an HS256 token using the public key as the secret
+	public, _ := json.Marshal(rsaKey.PublicKey)
+	if _, err := v.Verify(sign(t, jwt.HS256, public, map[string]any{})); !errors.Is(err, jwt.ErrSignature) {
+		t.Errorf("got %v, want %v", err, jwt.ErrSignature)
+	}
+
+	v = jwt.New(jwt.Config{Keys: jwt.NewKeySet(jwt.Key{Algorithm: jwt.HS256, Key: "secret"}), Algorithms: []string{jwt.RS256}})
+	if _, err := v.Verify(sign(t, jwt.HS256, []byte("secret"), map[string]any{})); !errors.Is(err, jwt.ErrAlgorithm) {
+		t.Errorf("got %v, want %v", err, jwt.ErrAlgorithm)
+	}
+	none := b64([]byte(`{"alg":"none"}`)) + "." + b64([]byte(`{}`)) + "."
+	if _, err := v.Verify(none); !errors.Is(err, jwt.ErrAlgorithm) {
+		t.Errorf("alg none: got %v, want %v", err, jwt.ErrAlgorithm)
+	}
+}
+
+func TestVerifyES256RequiresP256(t *testing.T) {
+	small, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	header := b64([]byte(`{"alg":"ES256"}`)) + "." + b64([]byte(`{}`))
+	digest := sha256.Sum256([]byte(header))
+	r, s, err := ecdsa.Sign(rand.Reader, small, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	// padded to the 64 bytes of an ES256 signature, a valid P-224 signature
+	signature := append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	v := verifier(jwt.Key{Algorithm: jwt.ES256, Key: small})
+	if _, err := v.Verify(header + "." + b64(signature)); !errors.Is(err, jwt.ErrSignature) {
+		t.Errorf("P-224 key: got %v, want %v", err, jwt.ErrSignature)
+	}
+}
+
+func TestVerifyTimes(t *testing.T) {
+	secret := []byte("secret")
+	v := verifier(jwt.Key{Algorithm: jwt.HS256, Key: secret})
+	unix := func(d time.Duration) float64 { return float64(now.Add(d).UnixNano()) / 1e9 }
+	tests := []struct {
+		name   string
+		claims map[string]any
+		want   error
+	}{
+		{"no times", map[string]any{}, nil},
+		{"not expired", map[string]any{"exp": unix(time.Hour)}, nil},
+		{"expired within leeway", map[string]any{"exp": unix(-30 * time.Second)}, nil},
+		{"expired", map[string]any{"exp": unix(-2 * time.Minute)}, jwt.ErrExpired},
+		{"expires at leeway", map[string]any{"exp": unix(-time.Minute)}, jwt.ErrExpired},
+		{"fractional exp", map[string]any{"exp": unix(-time.Minute + time.Millisecond)}, nil},
+		{"far future exp", map[string]any{"exp": 9999999999}, nil},
+		{"exp beyond 2262", map[string]any{"exp": 1e13}, nil},
+		{"nbf passed", map[string]any{"nbf": unix(-time.Hour)}, nil},
+		{"nbf within leeway", map[string]any{"nbf": unix(30 * time.Second)}, nil},
+		{"nbf ahead", map[string]any{"nbf": unix(2 * time.Minute)}, jwt.ErrNotYetValid},
+		{"iat passed", map[string]any{"iat": unix(-time.Hour)}, nil},
+		{"iat within leeway", map[string]any{"iat": unix(30 * time.Second)}, nil},
+		{"iat ahead", map[string]any{"iat": unix(2 * time.Minute)}, jwt.ErrIssuedAt},
+		{"exp out of range", map[string]any{"exp": 1e300}, jwt.ErrMalformed},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := v.Verify(sign(t, jwt.HS256, secret, test.claims))
+			if !errors.Is(err, test.want) {
+				t.Errorf("got %v, want %v", err, test.want)
+			}
+		})
+	}
+}
+
+func TestVerifyIssuerAndAudience(t *testing.T) {
+	secret := []byte("secret")
+	v := jwt.New(jwt.Config{
+		Keys:     jwt.NewKeySet(jwt.Key{Algorithm: jwt.HS256, Key: secret}),
+		Issuer:   "https://issuer",
+		Audience: "api",
+	})
+	tests := []struct {
+		claims map[string]any
+		want   error
+	}{
+		{map[string]any{"iss": "https://issuer", "aud": "api"}, nil},
+		{map[string]any{"iss": "https://issuer", "aud": []string{"web", "api"}}, nil},
+		{map[string]any{"iss": "https://other", "aud": "api"}, jwt.ErrIssuer},
+		{map[string]any{"iss": "https://issuer", "aud": "web"}, jwt.ErrAudience},
+	}
+	for _, test := range tests {
+		if _, err := v.Verify(sign(t, jwt.HS256, secret, test.claims)); !errors.Is(err, test.want) {
+			t.Errorf("%v: got %v, want %v", test.claims, err, test.want)
+		}
+	}
+}
+
+func TestNumericDate(t *testing.T) {
+	tests := []struct {
+		json string
+		want time.Time
+	}{
+		{"1700000000", time.Unix(1_700_000_000, 0)},
+		{"1700000000.25", time.Unix(1_700_000_000, 250_000_000)},
+		{"9999999999", time.Unix(9_999_999_999, 0)},
+		{"-1.5", time.Unix(-1, -500_000_000)},
+	}
+	for _, test := range tests {
+		var d jwt.NumericDate
+		if err := json.Unmarshal([]byte(test.json), &d); err != nil {
+			t.Errorf("%s: %v", test.json, err)
+			continue
+		}
+		if !d.Equal(test.want) {
+			t.Errorf("%s: got %v, want %v", test.json, d.Time, test.want)
+		}
+	}
+	var d jwt.NumericDate
+	if err := json.Unmarshal([]byte("1e30"), &d); err == nil {
+		t.Errorf("1e30: got %v, want an error", d.Time)
+	}
+}
@@ -0,0 +1,228 @@
+package faust
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	latencyBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+	sizeBuckets    = []float64{100, 1000, 10000, 100000, 1000000, 10000000}
+)
+
+type histogram struct {
+	buckets []float64
+	counts  []uint64
+	sum     float64
+	count   uint64
+}
+
+func newHistogram(buckets []float64) *histogram {
+	return &histogram{buckets: buckets, counts: make([]uint64, len(buckets))}
+}
+
+func (h *histogram) observe(v float64) {
+	for i, upper := range h.buckets {
+		if v <= upper {
+			h.counts[i]++
+		}
+	}
+	h.sum += v
+	h.count++
+}
+
+type routeKey struct {
+	method string
+	route  string
+}
+
+type requestKey struct {
+	routeKey
+	status int
+}
+
+type validationKey struct {
+	routeKey
+	in   string
+	name string
+}
+
+// Metrics collects request metrics of an API, labelled by method and route
+// template, and serves them in the Prometheus text exposition format.
+type Metrics struct {
+	api        *API
+	lock       sync.Mutex
+	requests   map[requestKey]uint64
+	latency    map[routeKey]*histogram
+	sizes      map[routeKey]*histogram
+	validation map[validationKey]uint64
+}
+
+// Metrics starts collecting metrics for every endpoint of the API and its
+// subrouters and serves them at /metrics.
+func (api *API) Metrics() *Metrics {
+	api.checkNotBuilt()
+	if api.metrics != nil {
+		return api.metrics
+	}
+	api.metrics = &Metrics{
+		api:        api,
+		requests:   map[requestKey]uint64{},
+		latency:    map[routeKey]*histogram{},
+		sizes:      map[routeKey]*histogram{},
+		validation: map[validationKey]uint64{},
+	}
+	api.Mux.Handle("/metrics", api.metrics).Methods("GET")
+	return api.metrics
+}
+
+func (api *API) metricsCollector() *Metrics {
+	for a := api; a != nil; a = a.parent {
+		if a.metrics != nil {
+			return a.metrics
+		}
+	}
+	return nil
+}
+
+func (e *Endpoint) routeKey() routeKey {
+	return routeKey{method: e.Method, route: e.api.prefix() + e.Path}
+}
+
+func (m *Metrics) observe(e *Endpoint, rw *responseWriter, latency time.Duration) {
+	key := e.routeKey()
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.requests[requestKey{routeKey: key, status: rw.Status()}]++
+	if m.latency[key] == nil {
+		m.latency[key] = newHistogram(latencyBuckets)
+		m.sizes[key] = newHistogram(sizeBuckets)
+	}
+	m.latency[key].observe(latency.Seconds())
+	m.sizes[key].observe(float64(rw.bytes))
+}
+
+func (m *Metrics) validationFailed(e *Endpoint, param IParam) {
+	key := validationKey{routeKey: e.routeKey(), in: "unknown", name: "unknown"}
+	if describer, ok := param.(ParamDescriber); ok {
+		key.in, key.name, _ = describer.ParamInfo()
+	}
+	m.lock.Lock()
+	m.validation[key]++
+	m.lock.Unlock()
+}
+
+func (m *Metrics) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	m.WriteTo(w)
+}
+
+// WriteTo writes the metrics in the Prometheus text exposition format.
+func (m *Metrics) WriteTo(w io.Writer) (int64, error) {
+	var b strings.Builder
+	m.lock.Lock()
+
+	header(&b, "faust_http_requests_total", "counter", "Total number of HTTP requests handled by an endpoint.")
+	requests := make([]requestKey, 0, len(m.requests))
+	for key := range m.requests {
+		requests = append(requests, key)
+	}
+	sort.Slice(requests, func(i, j int) bool {
+		if requests[i].routeKey != requests[j].routeKey {
+			return requests[i].routeKey.less(requests[j].routeKey)
+		}
+		return requests[i].status < requests[j].status
+	})
+	for _, key := range requests {
+		fmt.Fprintf(&b, "faust_http_requests_total{%s,status=\"%d\"} %d\n", key.labels(), key.status, m.requests[key])
+	}
+
+	routes := make([]routeKey, 0, len(m.latency))
+	for key := range m.latency {
+		routes = append(routes, key)
+	}
+	sort.Slice(routes, func(i, j int) bool {
+		return routes[i].less(routes[j])
+	})
+	header(&b, "faust_http_request_duration_seconds", "histogram", "Time taken to respond to HTTP requests.")
+	for _, key := range routes {
+		writeHistogram(&b, "faust_http_request_duration_seconds", key.labels(), m.latency[key])
+	}
+	header(&b, "faust_http_response_size_bytes", "histogram", "Size of HTTP response bodies.")
+	for _, key := range routes {
+		writeHistogram(&b, "faust_http_response_size_bytes", key.labels(), m.sizes[key])
+	}
+
+	header(&b, "faust_http_validation_failures_total", "counter", "Total number of requests rejected by a parameter.")
+	failures := make([]validationKey, 0, len(m.validation))
+	for key := range m.validation {
+		failures = append(failures, key)
+	}
+	sort.Slice(failures, func(i, j int) bool {
+		a, c := failures[i], failures[j]
+		if a.routeKey != c.routeKey {
+			return a.routeKey.less(c.routeKey)
+		}
+		if a.in != c.in {
+			return a.in < c.in
+		}
+		return a.name < c.name
+	})
+	for _, key := range failures {
+		fmt.Fprintf(&b, "faust_http_validation_failures_total{%s,in=\"%s\",name=\"%s\"} %d\n",
+			key.labels(), escapeLabel(key.in), escapeLabel(key.name), m.validation[key])
+	}
+	m.lock.Unlock()
+
+	header(&b, "faust_http_requests_in_flight", "gauge", "Number of HTTP requests currently being handled by an endpoint.")
+	m.writeInFlight(&b, m.api)
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func (m *Metrics) writeInFlight(b *strings.Builder, api *API) {
+	for _, endpoint := range api.Endpoints {
+		fmt.Fprintf(b, "faust_http_requests_in_flight{%s} %d\n", endpoint.routeKey().labels(), atomic.LoadInt64(&endpoint.inFlight))
+	}
+	for _, sub := range api.Subrouters {
+		m.writeInFlight(b, sub)
+	}
+}
+
+func (k routeKey) less(o routeKey) bool {
+	if k.route != o.route {
+		return k.route < o.route
+	}
+	return k.method < o.method
+}
+
+func (k routeKey) labels() string {
+	return fmt.Sprintf("method=\"%s\",route=\"%s\"", escapeLabel(k.method), escapeLabel(k.route))
+}
+
+func header(b *strings.Builder, name, kind, help string) {
+	fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s %s\n", name, help, name, kind)
+}
+
+func writeHistogram(b *strings.Builder, name, labels string, h *histogram) {
+	for i, upper := range h.buckets {
+		fmt.Fprintf(b, "%s_bucket{%s,le=\"%s\"} %d\n", name, labels, strconv.FormatFloat(upper, 'g', -1, 64), h.counts[i])
+	}
+	fmt.Fprintf(b, "%s_bucket{%s,le=\"+Inf\"} %d\n", name, labels, h.count)
+	fmt.Fprintf(b, "%s_sum{%s} %s\n", name, labels, strconv.FormatFloat(h.sum, 'g', -1, 64))
+	fmt.Fprintf(b, "%s_count{%s} %d\n", name, labels, h.count)
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(s string) string {
+	return labelEscaper.Replace(s)
+}
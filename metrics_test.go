@@ -0,0 +1,47 @@
+package faust_test
+
+import (
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/param"
+	"net/http"
+	"regexp"
+	"testing"
+)
+
+// latencies are the samples of the request duration, they vary between runs.
+var latencies = regexp.MustCompile(`(?m)^(faust_http_request_duration_seconds_(?:bucket|sum)\{.*\}) .*$`)
+
+func TestMetricsExposition(t *testing.T) {
+	api := faust.New()
+	api.Metrics()
+	api.Get(`/say/"hi"\`, func(e *faust.Endpoint) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("hi"))
+		}
+	})
+	users := api.Subrouter("/users")
+	users.Get("/{id}", func(e *faust.Endpoint) http.HandlerFunc {
+		param.Path[int](e, "id")
+		param.Query[int](e, "odd\"na\\me\n")
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write(make([]byte, 2000))
+		}
+	})
+	users.Delete("/{id}", func(e *faust.Endpoint) http.HandlerFunc {
+		param.Path[int](e, "id")
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		}
+	})
+	send(api, "GET", `/say/"hi"\`, nil)
+	send(api, "GET", "/users/1?odd%22na%5Cme%0A=2", nil)
+	send(api, "GET", "/users/1", nil)
+	send(api, "GET", "/users/abc", nil)
+	send(api, "DELETE", "/users/1", nil)
+
+	w := send(api, "GET", "/metrics", nil)
+	if got := w.Header().Get("Content-Type"); got != "text/plain; version=0.0.4; charset=utf-8" {
+		t.Errorf("got Content-Type %q", got)
+	}
+	golden(t, "metrics.golden", latencies.ReplaceAllString(w.Body.String(), "$1 <latency>"))
+}
@@ -0,0 +1,171 @@
+// Package openapi holds the subset of the OpenAPI 3 document model faust
+// generates and reads.
+package openapi
+
+import "strings"
+
+type Document struct {
+	OpenAPI    string               `json:"openapi" yaml:"openapi"`
+	Info       Info                 `json:"info" yaml:"info"`
+	Paths      map[string]*PathItem `json:"paths" yaml:"paths"`
+	Components *Components          `json:"components,omitempty" yaml:"components,omitempty"`
+	// Security applies to the operations without a security of their own.
+	Security []map[string][]string `json:"security,omitempty" yaml:"security,omitempty"`
+}
+
+type Info struct {
+	Title       string `json:"title" yaml:"title"`
+	Summary     string `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string `json:"description,omitempty" yaml:"description,omitempty"`
+	Version     string `json:"version" yaml:"version"`
+}
+
+type PathItem struct {
+	// Parameters apply to every operation of the path, Parse merges them
+	// into the operations.
+	Parameters []*Parameter `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	Get        *Operation   `json:"get,omitempty" yaml:"get,omitempty"`
+	Put        *Operation   `json:"put,omitempty" yaml:"put,omitempty"`
+	Post       *Operation   `json:"post,omitempty" yaml:"post,omitempty"`
+	Delete     *Operation   `json:"delete,omitempty" yaml:"delete,omitempty"`
+	Options    *Operation   `json:"options,omitempty" yaml:"options,omitempty"`
+	Head       *Operation   `json:"head,omitempty" yaml:"head,omitempty"`
+	Patch      *Operation   `json:"patch,omitempty" yaml:"patch,omitempty"`
+	Trace      *Operation   `json:"trace,omitempty" yaml:"trace,omitempty"`
+}
+
+// Operations returns the operations of the path item by upper case method.
+func (p *PathItem) Operations() map[string]*Operation {
+	operations := map[string]*Operation{}
+	for method, operation := range map[string]*Operation{
+		"GET": p.Get, "PUT": p.Put, "POST": p.Post, "DELETE": p.Delete,
+		"OPTIONS": p.Options, "HEAD": p.Head, "PATCH": p.Patch, "TRACE": p.Trace,
+	} {
+		if operation != nil {
+			operations[method] = operation
+		}
+	}
+	return operations
+}
+
+// mergeParameters adds the parameters of the path item to its operations,
+// an operation parameter with the same location and name overrides one of the
+// path item.
+func (p *PathItem) mergeParameters(components *Components) {
+	key := func(parameter *Parameter) string {
+		if parameter.Ref != "" && components != nil {
+			if resolved := components.Parameters[parameter.Ref[strings.LastIndex(parameter.Ref, "/")+1:]]; resolved != nil {
+				parameter = resolved
+			}
+		}
+		if parameter.Ref != "" {
+			return parameter.Ref
+		}
+		return parameter.In + " " + parameter.Name
+	}
+	for _, operation := range p.Operations() {
+		declared := map[string]bool{}
+		for _, parameter := range operation.Parameters {
+			if parameter != nil {
+				declared[key(parameter)] = true
+			}
+		}
+		for _, parameter := range p.Parameters {
+			if parameter != nil && !declared[key(parameter)] {
+				operation.Parameters = append(operation.Parameters, parameter)
+			}
+		}
+	}
+}
+
+// SetOperation sets the operation of an upper case method, it reports false
+// for methods OpenAPI can't describe.
+func (p *PathItem) SetOperation(method string, operation *Operation) bool {
+	switch method {
+	case "GET":
+		p.Get = operation
+	case "PUT":
+		p.Put = operation
+	case "POST":
+		p.Post = operation
+	case "DELETE":
+		p.Delete = operation
+	case "OPTIONS":
+		p.Options = operation
+	case "HEAD":
+		p.Head = operation
+	case "PATCH":
+		p.Patch = operation
+	case "TRACE":
+		p.Trace = operation
+	default:
+		return false
+	}
+	return true
+}
+
+type Operation struct {
+	OperationID string                `json:"operationId,omitempty" yaml:"operationId,omitempty"`
+	Summary     string                `json:"summary,omitempty" yaml:"summary,omitempty"`
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	Tags        []string              `json:"tags,omitempty" yaml:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty" yaml:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses" yaml:"responses"`
+	Security    []map[string][]string `json:"security,omitempty" yaml:"security,omitempty"`
+}
+
+type Parameter struct {
+	Ref         string  `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Name        string  `json:"name,omitempty" yaml:"name,omitempty"`
+	In          string  `json:"in,omitempty" yaml:"in,omitempty"`
+	Description string  `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool    `json:"required,omitempty" yaml:"required,omitempty"`
+	Schema      *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+type RequestBody struct {
+	Ref         string                `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	Required    bool                  `json:"required,omitempty" yaml:"required,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty" yaml:"schema,omitempty"`
+}
+
+type Response struct {
+	Ref         string                `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Description string                `json:"description,omitempty" yaml:"description,omitempty"`
+	Content     map[string]*MediaType `json:"content,omitempty" yaml:"content,omitempty"`
+}
+
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty" yaml:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty" yaml:"type,omitempty"`
+	Format               string             `json:"format,omitempty" yaml:"format,omitempty"`
+	Description          string             `json:"description,omitempty" yaml:"description,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty" yaml:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty" yaml:"required,omitempty"`
+	Items                *Schema            `json:"items,omitempty" yaml:"items,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty" yaml:"additionalProperties,omitempty"`
+	Enum                 []any              `json:"enum,omitempty" yaml:"enum,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty" yaml:"minimum,omitempty"`
+	Nullable             bool               `json:"nullable,omitempty" yaml:"nullable,omitempty"`
+}
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty" yaml:"schemas,omitempty"`
+	Parameters      map[string]*Parameter      `json:"parameters,omitempty" yaml:"parameters,omitempty"`
+	RequestBodies   map[string]*RequestBody    `json:"requestBodies,omitempty" yaml:"requestBodies,omitempty"`
+	Responses       map[string]*Response       `json:"responses,omitempty" yaml:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty" yaml:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type         string `json:"type" yaml:"type"`
+	Scheme       string `json:"scheme,omitempty" yaml:"scheme,omitempty"`
+	BearerFormat string `json:"bearerFormat,omitempty" yaml:"bearerFormat,omitempty"`
+	Description  string `json:"description,omitempty" yaml:"description,omitempty"`
+}
@@ -0,0 +1,499 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Parse reads an OpenAPI document written as JSON or YAML. YAML support
+// covers what OpenAPI documents use: block and flow collections, plain and
+// quoted scalars, literal and folded block scalars and comments. Anchors,
+// aliases and tags are rejected. The parameters of path items are merged into
+// their operations.
+func Parse(data []byte) (*Document, error) {
+	if trimmed := bytes.TrimSpace(data); len(trimmed) == 0 || trimmed[0] != '{' {
+		value, err := parseYAML(string(data))
+		if err != nil {
+			return nil, err
+		}
+		// decode through encoding/json so the json tags of the model apply
+		data, err = json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+	}
+	var doc Document
+	if err := json.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	for _, item := range doc.Paths {
+		if item != nil {
+			item.mergeParameters(doc.Components)
+		}
+	}
+	return &doc, nil
+}
+
+// stringKeys are the keys the document model holds as strings, e.g.
+// "version: 1.0" is a version and not a number.
+var stringKeys = map[string]bool{
+	"openapi": true, "title": true, "summary": true, "description": true, "version": true,
+	"operationId": true, "name": true, "in": true, "$ref": true, "type": true, "format": true,
+	"scheme": true, "bearerFormat": true,
+}
+
+type yamlLine struct {
+	number int
+	indent int
+	text   string
+}
+
+type yamlParser struct {
+	raw   []string
+	lines []yamlLine
+	pos   int
+}
+
+func parseYAML(src string) (any, error) {
+	p := &yamlParser{raw: strings.Split(strings.ReplaceAll(src, "\r\n", "\n"), "\n")}
+	for i, raw := range p.raw {
+		text := strings.TrimLeft(raw, " ")
+		if strings.HasPrefix(text, "\t") {
+			return nil, fmt.Errorf("yaml: line %d: tabs can't indent", i+1)
+		}
+		if i == 0 && strings.HasPrefix(text, "%") || text == "---" {
+			continue
+		}
+		if text == "..." {
+			break
+		}
+		p.lines = append(p.lines, yamlLine{number: i + 1, indent: len(raw) - len(text), text: text})
+	}
+	p.pos = -1
+	p.advance()
+	if p.pos >= len(p.lines) {
+		return map[string]any{}, nil
+	}
+	value, err := p.node(p.lines[p.pos].indent)
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.lines) {
+		return nil, p.errorf("unexpected indentation")
+	}
+	return value, nil
+}
+
+// advance moves to the next line with content, comments are stripped from
+// lines as they are reached since block scalars keep theirs.
+func (p *yamlParser) advance() {
+	for p.pos++; p.pos < len(p.lines); p.pos++ {
+		line := &p.lines[p.pos]
+		line.text = strings.TrimRight(stripComment(line.text), " \t")
+		if line.text != "" {
+			return
+		}
+	}
+}
+
+func (p *yamlParser) errorf(format string, args ...any) error {
+	number := len(p.raw)
+	if p.pos < len(p.lines) {
+		number = p.lines[p.pos].number
+	}
+	return fmt.Errorf("yaml: line %d: %s", number, fmt.Sprintf(format, args...))
+}
+
+func (p *yamlParser) node(indent int) (any, error) {
+	line := p.lines[p.pos]
+	switch {
+	case line.text == "-" || strings.HasPrefix(line.text, "- "):
+		return p.sequence(indent)
+	case isKey(line.text):
+		return p.mapping(indent)
+	}
+	return p.scalar(indent, line.text)
+}
+
+// scalar parses text, a flow collection or a scalar that may continue on
+// lines indented deeper than indent.
+func (p *yamlParser) scalar(indent int, text string) (any, error) {
+	number := p.lines[p.pos].number
+	plain := !strings.ContainsAny(text[:1], "[{\"'")
+	for p.advance(); p.pos < len(p.lines) && p.lines[p.pos].indent > indent; p.advance() {
+		if plain && isKey(p.lines[p.pos].text) {
+			return nil, p.errorf("unexpected key in a multiline scalar")
+		}
+		text += " " + p.lines[p.pos].text
+	}
+	return inline(text, number)
+}
+
+func (p *yamlParser) sequence(indent int) (any, error) {
+	items := []any{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line := &p.lines[p.pos]
+		if line.text != "-" && !strings.HasPrefix(line.text, "- ") {
+			break
+		}
+		rest := strings.TrimLeft(strings.TrimPrefix(line.text, "-"), " ")
+		if rest == "" {
+			p.advance()
+			if p.pos >= len(p.lines) || p.lines[p.pos].indent <= indent {
+				items = append(items, nil)
+				continue
+			}
+			item, err := p.node(p.lines[p.pos].indent)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+		if rest[0] == '|' || rest[0] == '>' {
+			item, err := p.blockScalar(indent, rest)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			continue
+		}
+		// "- key: value" starts a mapping indented by the dash, parse the
+		// rest of the line as if it were on its own
+		line.indent += len(line.text) - len(rest)
+		line.text = rest
+		item, err := p.node(line.indent)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
+
+func (p *yamlParser) mapping(indent int) (any, error) {
+	values := map[string]any{}
+	for p.pos < len(p.lines) && p.lines[p.pos].indent == indent {
+		line := p.lines[p.pos]
+		if !isKey(line.text) {
+			return nil, p.errorf("expected a key")
+		}
+		key, rest, err := splitKey(line.text)
+		if err != nil {
+			return nil, p.errorf("%v", err)
+		}
+		if _, ok := values[key]; ok {
+			return nil, p.errorf("duplicate key %q", key)
+		}
+		var value any
+		switch {
+		case rest == "":
+			p.advance()
+			if p.pos < len(p.lines) {
+				next := p.lines[p.pos]
+				// sequences may be indented as much as their key
+				if next.indent > indent || next.indent == indent && (next.text == "-" || strings.HasPrefix(next.text, "- ")) {
+					value, err = p.node(next.indent)
+				}
+			}
+		case rest[0] == '|' || rest[0] == '>':
+			value, err = p.blockScalar(indent, rest)
+		default:
+			value, err = p.scalar(indent, rest)
+			if _, ok := value.(string); !ok && stringKeys[key] && !strings.ContainsAny(rest[:1], "[{\"'") {
+				value = rest
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		values[key] = value
+	}
+	return values, nil
+}
+
+// blockScalar reads a literal (|) or folded (>) scalar from the raw lines
+// after the current one.
+func (p *yamlParser) blockScalar(indent int, header string) (any, error) {
+	chomp := byte(0)
+	if strings.ContainsAny(header, "-+") {
+		chomp = header[strings.IndexAny(header, "-+")]
+	}
+	start := p.lines[p.pos].number // raw index of the next line
+	end := start
+	blockIndent := -1
+	var lines []string
+	for ; end < len(p.raw); end++ {
+		raw := p.raw[end]
+		text := strings.TrimLeft(raw, " ")
+		if text == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if len(raw)-len(text) <= indent {
+			break
+		}
+		if blockIndent < 0 {
+			blockIndent = len(raw) - len(text)
+		}
+		if len(raw)-len(text) < blockIndent {
+			break
+		}
+		lines = append(lines, raw[blockIndent:])
+	}
+	// trailing blank lines belong to the scalar only for chomping
+	trailing := 0
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+		trailing++
+	}
+	var text string
+	if header[0] == '|' {
+		text = strings.Join(lines, "\n")
+	} else {
+		var b strings.Builder
+		for i, line := range lines {
+			switch {
+			case i == 0, lines[i-1] == "":
+			case line == "" || strings.HasPrefix(line, " "):
+				b.WriteString("\n")
+			default:
+				b.WriteString(" ")
+			}
+			b.WriteString(line)
+		}
+		text = b.String()
+	}
+	switch {
+	case len(lines) == 0:
+	case chomp == '+':
+		text += strings.Repeat("\n", trailing+1)
+	case chomp != '-':
+		text += "\n"
+	}
+	for p.pos < len(p.lines) && p.lines[p.pos].number <= end {
+		p.pos++
+	}
+	p.pos--
+	p.advance()
+	return text, nil
+}
+
+// inline parses a flow collection or a scalar starting on line number.
+func inline(text string, number int) (any, error) {
+	s := &flowScanner{text: text}
+	value, err := s.value(false)
+	if err != nil {
+		return nil, fmt.Errorf("yaml: line %d: %v", number, err)
+	}
+	s.space()
+	if s.pos < len(s.text) {
+		return nil, fmt.Errorf("yaml: line %d: unexpected %q", number, s.text[s.pos:])
+	}
+	return value, nil
+}
+
+type flowScanner struct {
+	text string
+	pos  int
+}
+
+func (s *flowScanner) space() {
+	for s.pos < len(s.text) && s.text[s.pos] == ' ' {
+		s.pos++
+	}
+}
+
+func (s *flowScanner) value(inFlow bool) (any, error) {
+	s.space()
+	if s.pos >= len(s.text) {
+		return nil, nil
+	}
+	switch s.text[s.pos] {
+	case '[':
+		s.pos++
+		items := []any{}
+		for {
+			s.space()
+			if s.pos < len(s.text) && s.text[s.pos] == ']' {
+				s.pos++
+				return items, nil
+			}
+			item, err := s.value(true)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+			if err := s.separator(']'); err != nil {
+				return nil, err
+			}
+		}
+	case '{':
+		s.pos++
+		values := map[string]any{}
+		for {
+			s.space()
+			if s.pos < len(s.text) && s.text[s.pos] == '}' {
+				s.pos++
+				return values, nil
+			}
+			key, err := s.value(true)
+			if err != nil {
+				return nil, err
+			}
+			s.space()
+			if s.pos >= len(s.text) || s.text[s.pos] != ':' {
+				return nil, fmt.Errorf("expected : after key %v", key)
+			}
+			s.pos++
+			value, err := s.value(true)
+			if err != nil {
+				return nil, err
+			}
+			values[fmt.Sprint(key)] = value
+			if err := s.separator('}'); err != nil {
+				return nil, err
+			}
+		}
+	case '"', '\'':
+		end := quoteEnd(s.text, s.pos)
+		if end < 0 {
+			return nil, fmt.Errorf("unterminated string")
+		}
+		value, err := unquote(s.text[s.pos : end+1])
+		s.pos = end + 1
+		return value, err
+	case '&', '*', '!':
+		return nil, fmt.Errorf("anchors, aliases and tags are not supported")
+	}
+	start := s.pos
+	for s.pos < len(s.text) {
+		c := s.text[s.pos]
+		if inFlow && (c == ',' || c == ']' || c == '}' || c == ':' && (s.pos+1 == len(s.text) || s.text[s.pos+1] == ' ')) {
+			break
+		}
+		s.pos++
+	}
+	return scalar(strings.TrimSpace(s.text[start:s.pos])), nil
+}
+
+func (s *flowScanner) separator(end byte) error {
+	s.space()
+	if s.pos >= len(s.text) {
+		return fmt.Errorf("unterminated flow collection")
+	}
+	switch s.text[s.pos] {
+	case ',':
+		s.pos++
+		return nil
+	case end:
+		return nil
+	}
+	return fmt.Errorf("unexpected %q in flow collection", s.text[s.pos])
+}
+
+// scalar resolves a plain scalar to null, a bool, a number or a string.
+func scalar(text string) any {
+	switch text {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return true
+	case "false", "False", "FALSE":
+		return false
+	}
+	if i, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return i
+	}
+	if f, err := strconv.ParseFloat(text, 64); err == nil && !math.IsInf(f, 0) && !math.IsNaN(f) && !strings.ContainsAny(text, "xX_") {
+		return f
+	}
+	return text
+}
+
+func unquote(text string) (string, error) {
+	if text[0] == '\'' {
+		return strings.ReplaceAll(text[1:len(text)-1], "''", "'"), nil
+	}
+	// YAML double quoted escapes are a superset of JSON's for the common cases
+	var value string
+	if err := json.Unmarshal([]byte(strings.ReplaceAll(text, `\/`, "/")), &value); err != nil {
+		return "", fmt.Errorf("invalid string %s", text)
+	}
+	return value, nil
+}
+
+// quoteEnd returns the index of the quote closing the string at start.
+func quoteEnd(text string, start int) int {
+	quote := text[start]
+	for i := start + 1; i < len(text); i++ {
+		switch {
+		case quote == '"' && text[i] == '\\':
+			i++
+		case text[i] == quote && quote == '\'' && i+1 < len(text) && text[i+1] == '\'':
+			i++
+		case text[i] == quote:
+			return i
+		}
+	}
+	return -1
+}
+
+func stripComment(text string) string {
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '"' || c == '\'':
+			// only quotes starting a scalar open a string
+			if i == 0 || strings.ContainsRune(" [{,:-", rune(text[i-1])) {
+				if end := quoteEnd(text, i); end > 0 {
+					i = end
+				}
+			}
+		case c == '#' && (i == 0 || text[i-1] == ' '):
+			return text[:i]
+		}
+	}
+	return text
+}
+
+// isKey reports whether text starts a block mapping entry.
+func isKey(text string) bool {
+	_, _, err := splitKey(text)
+	return err == nil
+}
+
+func splitKey(text string) (key, rest string, err error) {
+	if text[0] == '[' || text[0] == '{' {
+		return "", "", fmt.Errorf("not a key")
+	}
+	end := 0
+	if text[0] == '"' || text[0] == '\'' {
+		end = quoteEnd(text, 0) + 1
+		if end == 0 {
+			return "", "", fmt.Errorf("unterminated key")
+		}
+		key, err = unquote(text[:end])
+		if err != nil {
+			return "", "", err
+		}
+		if !strings.HasPrefix(text[end:], ":") {
+			return "", "", fmt.Errorf("not a key")
+		}
+	} else {
+		for end < len(text) && !(text[end] == ':' && (end+1 == len(text) || text[end+1] == ' ')) {
+			end++
+		}
+		if end == len(text) {
+			return "", "", fmt.Errorf("not a key")
+		}
+		key = strings.TrimSpace(text[:end])
+	}
+	if end+1 < len(text) && text[end+1] != ' ' {
+		return "", "", fmt.Errorf("not a key")
+	}
+	return key, strings.TrimSpace(text[end+1:]), nil
+}
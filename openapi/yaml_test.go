@@ -0,0 +1,155 @@
+package openapi
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type m = map[string]any
+type s = []any
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name, src string
+		want      any
+	}{
+		{"empty", "", m{}},
+		{"document markers", "%YAML 1.2\n---\na: 1\n...\nignored: [", m{"a": int64(1)}},
+		{"nested mappings", "a:\n  b:\n    c: x\n  d: y\ne: z", m{"a": m{"b": m{"c": "x"}, "d": "y"}, "e": "z"}},
+		{"empty value", "a:\nb: 1", m{"a": nil, "b": int64(1)}},
+		{"sequence", "- a\n- b\n-\n  - c", s{"a", "b", s{"c"}}},
+		{"sequence of mappings", "- name: a\n  in: path\n- name: b", s{m{"name": "a", "in": "path"}, m{"name": "b"}}},
+		{"sequence at key indent", "tags:\n- a\n- b\nnext: 1", m{"tags": s{"a", "b"}, "next": int64(1)}},
+		{"empty sequence item", "- \n- a", s{nil, "a"}},
+		{"flow sequence", "a: [1, two, 'three', [4]]", m{"a": s{int64(1), "two", "three", s{int64(4)}}}},
+		{"flow mapping", "a: {type: string, enum: [x, y], n: {}}", m{"a": m{"type": "string", "enum": s{"x", "y"}, "n": m{}}}},
+		{"flow over lines", "a: {type: string,\n  format: uuid}", m{"a": m{"type": "string", "format": "uuid"}}},
+		{"flow url", "a: [http://x.dev/a, b]", m{"a": s{"http://x.dev/a", "b"}}},
+		{"scalars", "a: ~\nb: null\nc: true\nd: False\ne: -12\nf: 1.5\ng: 1e3\nh: 0x10\ni: 1_000\nj: .inf",
+			m{"a": nil, "b": nil, "c": true, "d": false, "e": int64(-12), "f": 1.5, "g": 1000.0, "h": "0x10", "i": "1_000", "j": ".inf"}},
+		{"string keys", "version: 1.0\ntitle: 2\ntype: true\nminimum: 1.0", m{"version": "1.0", "title": "2", "type": "true", "minimum": 1.0}},
+		{"plain multiline", "a: one\n  two\n  - three\nb: x", m{"a": "one two - three", "b": "x"}},
+		{"plain with colons", "a: b:c\nurl: http://x.dev", m{"a": "b:c", "url": "http://x.dev"}},
+		{"single quotes", `a: 'it''s # not a comment'`, m{"a": "it's # not a comment"}},
+		{"double quotes", `a: "tab\there \u00e9 \"q\" \/ # x"`, m{"a": "tab\there é \"q\" / # x"}},
+		{"quoted keys", "\"a b\": 1\n'$ref': x\n\"200\": ok", m{"a b": int64(1), "$ref": "x", "200": "ok"}},
+		{"quoted numbers", `a: "1"`, m{"a": "1"}},
+		{"comments", "# head\na: 1 # trailing\n  # indented\nb: x#y\nc: [1, 2] # flow", m{"a": int64(1), "b": "x#y", "c": s{int64(1), int64(2)}}},
+		{"literal", "a: |\n  one\n    two\n\n  three\nb: x", m{"a": "one\n  two\n\nthree\n", "b": "x"}},
+		{"literal keeps comments", "a: |\n  # not a comment\nb: x", m{"a": "# not a comment\n", "b": "x"}},
+		{"literal strip", "a: |-\n  one\n  two\n\nb: x", m{"a": "one\ntwo", "b": "x"}},
+		{"literal keep", "a: |+\n  one\n\n\nb: x", m{"a": "one\n\n\n", "b": "x"}},
+		{"folded", "a: >\n  one\n  two\n\n  three\n    indented\nb: x", m{"a": "one two\nthree\n  indented\n", "b": "x"}},
+		{"folded strip", "a: >-\n  one\n  two\n", m{"a": "one two"}},
+		{"block scalar in sequence", "- |\n  one\n- two", s{"one\n", "two"}},
+		{"empty block scalar", "a: |\nb: x", m{"a": "", "b": "x"}},
+		{"windows newlines", "a: 1\r\nb:\r\n  - x\r\n", m{"a": int64(1), "b": s{"x"}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseYAML(test.src)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, test.want) {
+				t.Errorf("got %#v\nwant %#v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name, src, err string
+	}{
+		{"anchor", "a: &x 1\nb: 2", "line 1: anchors, aliases and tags are not supported"},
+		{"alias", "a: 1\nb: *x", "line 2: anchors, aliases and tags are not supported"},
+		{"flow alias", "a: [1, *x]", "anchors, aliases and tags are not supported"},
+		{"tag", "a: !!str 1", "anchors, aliases and tags are not supported"},
+		{"merge key", "a:\n  <<: *base", "anchors, aliases and tags are not supported"},
+		{"tab", "a:\n\tb: 1", "line 2: tabs can't indent"},
+		{"duplicate key", "a: 1\na: 2", `line 2: duplicate key "a"`},
+		{"unterminated string", `a: "x`, "unterminated string"},
+		{"unterminated flow", "a: [1, 2", "unterminated flow collection"},
+		{"flow without colon", "a: {b}", "expected : after key"},
+		{"bad escape", `a: "\q"`, "invalid string"},
+		{"indentation", "a:\n    b: 1\n  c: 2", "line 3: unexpected indentation"},
+		{"key in plain scalar", "a: 1\nb: 2\n c: 3", "line 3: unexpected key in a multiline scalar"},
+		{"mixed collection", "a: 1\n- b", "line 2: expected a key"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseYAML(test.src)
+			if err == nil || !strings.Contains(err.Error(), test.err) {
+				t.Errorf("got %#v and error %v, want an error with %q", got, err, test.err)
+			}
+		})
+	}
+}
+
+func TestParse(t *testing.T) {
+	yamlDoc := `
+openapi: 3.0.3
+info:
+  title: Items
+  version: 1.0
+paths:
+  /items/{id}:
+    parameters:
+      - $ref: '#/components/parameters/id'
+      - {name: verbose, in: query, schema: {type: boolean}}
+    get:
+      operationId: getItem
+      parameters:
+        - name: verbose
+          in: query
+          required: true
+          schema: {type: integer}
+      responses:
+        "200":
+          description: >-
+            The item
+    delete:
+      responses:
+        "204": {description: Deleted}
+components:
+  parameters:
+    id: {name: id, in: path, required: true, schema: {type: integer}}
+`
+	jsonDoc := `{
+  "openapi": "3.0.3",
+  "info": {"title": "Items", "version": "1.0"},
+  "paths": {"/items/{id}": {
+    "parameters": [
+      {"$ref": "#/components/parameters/id"},
+      {"name": "verbose", "in": "query", "schema": {"type": "boolean"}}
+    ],
+    "get": {
+      "operationId": "getItem",
+      "parameters": [{"name": "verbose", "in": "query", "required": true, "schema": {"type": "integer"}}],
+      "responses": {"200": {"description": "The item"}}
+    },
+    "delete": {"responses": {"204": {"description": "Deleted"}}}
+  }},
+  "components": {"parameters": {"id": {"name": "id", "in": "path", "required": true, "schema": {"type": "integer"}}}}
+}`
+	fromYAML, err := Parse([]byte(yamlDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	fromJSON, err := Parse([]byte(jsonDoc))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(fromYAML, fromJSON) {
+		t.Errorf("the YAML and JSON documents differ:\n%#v\n%#v", fromYAML, fromJSON)
+	}
+	item := fromYAML.Paths["/items/{id}"]
+	if get := item.Get.Parameters; len(get) != 2 || get[0].Schema.Type != "integer" || get[1].Ref == "" {
+		t.Errorf("get has parameters %+v, want its own verbose and the path item's id", get)
+	}
+	if del := item.Delete.Parameters; len(del) != 2 || del[0].Ref == "" || del[1].Schema.Type != "boolean" {
+		t.Errorf("delete has parameters %+v, want those of the path item", del)
+	}
+}
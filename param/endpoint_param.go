@@ -5,16 +5,14 @@ import (
 	"fmt"
 	"github.com/gorilla/mux"
 	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/internal/reqkey"
 	cmap "github.com/orcaman/concurrent-map"
+	"io"
 	"net/http"
 	"reflect"
 	"strconv"
 )
 
-func reqToId(r *http.Request) string {
-	return fmt.Sprintf("%p", r)
-}
-
 func Query[T any](e *faust.Endpoint, name string, paramInfo ...Info) *EndpointParam[T] {
 	return Param[T]("query", e, name, paramInfo...)
 }
@@ -58,20 +56,48 @@ func Param[T any](ptype string, e *faust.Endpoint, name string, paramInfo ...Inf
 	}
 	param.parameterInfo.In = ptype
 	param.parameterInfo.Name = name
-	param.Schema.Type = tType.Kind().String()
-	param.Schema.Format = tType.Kind().String()
+	param.Schema = schemaOf(tType)
 	e.Params = append(e.Params, param)
 	return param
 }
 
+// Response documents a response of the endpoint with a body of type T, e.g.
+// param.Response[User](e, 200, "The user").
+func Response[T any](e *faust.Endpoint, status int, description string) {
+	t := reflect.TypeOf(new(T)).Elem()
+	e.Returns(status, description, schemaOf(t))
+	e.Responses[len(e.Responses)-1].Type = t
+}
+
+// RequestIDValue gives handlers access to the ID faust assigned to the
+// request, see faust.API.RequestID.
+type RequestIDValue struct{}
+
+func RequestID(e *faust.Endpoint) RequestIDValue {
+	return RequestIDValue{}
+}
+
+func (RequestIDValue) Value(r *http.Request) string {
+	return faust.RequestIDFrom(r.Context())
+}
+
 type ParameterSchema struct {
-	Type   string `json:"type,omitempty"`
-	Format string `json:"format,omitempty"`
+	// Name is the Go name of struct types.
+	Name                 string                     `json:"name,omitempty"`
+	Type                 string                     `json:"type,omitempty"`
+	Format               string                     `json:"format,omitempty"`
+	Properties           map[string]ParameterSchema `json:"properties,omitempty"`
+	Required             []string                   `json:"required,omitempty"`
+	Items                *ParameterSchema           `json:"items,omitempty"`
+	AdditionalProperties *ParameterSchema           `json:"additionalProperties,omitempty"`
+	Enum                 []any                      `json:"enum,omitempty"`
 }
 
 type Info struct {
 	Description string `json:"description,omitempty"`
 	Optional    bool   `json:"optional,omitempty"`
+	// Sensitive values are redacted from access logs.
+	Sensitive bool `json:"sensitive,omitempty"`
 }
 
 type parameterInfo struct {
@@ -86,10 +112,11 @@ type EndpointParam[T any] struct {
 	outType   reflect.Type
 	values    cmap.ConcurrentMap
 	validator []func(T) error
+	enum      []T
 }
 
 func (e *EndpointParam[T]) Dispose(r *http.Request) {
-	reqId := reqToId(r)
+	reqId := reqkey.Of(r)
 	e.values.Remove(reqId)
 }
 
@@ -98,6 +125,29 @@ func (e *EndpointParam[T]) Validate(validateFunc ...func(T) error) *EndpointPara
 	return e
 }
 
+// Enum restricts the parameter to values, other values are rejected and the
+// values are listed in the documentation.
+func (e *EndpointParam[T]) Enum(values ...T) *EndpointParam[T] {
+	e.enum = values
+	e.Schema.Enum = make([]any, len(values))
+	for i, value := range values {
+		e.Schema.Enum[i] = value
+	}
+	return e
+}
+
+func (e *EndpointParam[T]) checkEnum(val T) error {
+	if len(e.enum) == 0 {
+		return nil
+	}
+	for _, value := range e.enum {
+		if reflect.DeepEqual(val, value) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%v is not one of %v", val, e.enum)
+}
+
 func (e *EndpointParam[T]) Description(desc string) *EndpointParam[T] {
 	e.parameterInfo.Description = desc
 	return e
@@ -108,12 +158,55 @@ func (e *EndpointParam[T]) Optional() *EndpointParam[T] {
 	return e
 }
 
+func (e *EndpointParam[T]) Sensitive() *EndpointParam[T] {
+	e.parameterInfo.Sensitive = true
+	return e
+}
+
+func (e *EndpointParam[T]) ParamInfo() (in, name string, sensitive bool) {
+	return e.In, e.Name, e.Info.Sensitive
+}
+
+func (e *EndpointParam[T]) ParamValue(r *http.Request) (any, bool) {
+	return e.values.Get(reqkey.Of(r))
+}
+
+// ParamType is the Go type of the parameter value, e.g. for client generators.
+func (e *EndpointParam[T]) ParamType() reflect.Type {
+	return e.outType
+}
+
+func (e *EndpointParam[T]) ParamOptional() bool {
+	return e.Info.Optional
+}
+
+// ParamCheck reports whether value would be accepted as the raw value of the
+// parameter, e.g. when building URLs.
+func (e *EndpointParam[T]) ParamCheck(value string) error {
+	val, err := e.parse(value)
+	if err != nil {
+		return err
+	}
+	if err := e.checkEnum(val); err != nil {
+		return err
+	}
+	for _, validate := range e.validator {
+		if err := validate(val); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (e *EndpointParam[T]) Use(r *http.Request) error {
-	var err error
-	val, err := e.ValueWithError(r)
+	val, present, err := e.read(r)
 	if err != nil {
 		return err
 	}
+	// absent optional parameters are zero and needn't be one of the values
+	if err := e.checkEnum(val); err != nil && (present || !e.Info.Optional) {
+		return fmt.Errorf("%s (%v:%v)", err.Error(), e.In, e.Name)
+	}
 	if e.validator != nil {
 		for _, validate := range e.validator {
 			if err := validate(val); err != nil {
@@ -121,60 +214,87 @@ func (e *EndpointParam[T]) Use(r *http.Request) error {
 			}
 		}
 	}
-	e.values.Set(reqToId(r), val)
+	e.values.Set(reqkey.Of(r), val)
 	return nil
 }
 
 func (e *EndpointParam[T]) ValueWithError(r *http.Request) (T, error) {
-	if _, ok := e.values.Get(reqToId(r)); ok {
-		return e.Value(r), nil
+	val, _, err := e.read(r)
+	return val, err
+}
+
+// read returns the value of the parameter and whether the request has it,
+// absent optional parameters are zero.
+func (e *EndpointParam[T]) read(r *http.Request) (T, bool, error) {
+	if _, ok := e.values.Get(reqkey.Of(r)); ok {
+		return e.Value(r), true, nil
 	}
 
 	var t T
 	var value string
+	var present bool
 	switch e.parameterInfo.In {
 	case "query":
-		if !e.Info.Optional && !r.URL.Query().Has(e.parameterInfo.Name) {
-			return t, fmt.Errorf("missing required parameter %s", e.parameterInfo.Name)
+		present = r.URL.Query().Has(e.parameterInfo.Name)
+		if !e.Info.Optional && !present {
+			return t, false, fmt.Errorf("missing required parameter %s", e.parameterInfo.Name)
 		}
 		value = r.URL.Query().Get(e.parameterInfo.Name)
 	case "path":
-		var exists bool
-		value, exists = mux.Vars(r)[e.parameterInfo.Name]
-		if !e.Info.Optional && !exists {
-			return t, fmt.Errorf("missing required parameter %s", e.parameterInfo.Name)
+		value, present = mux.Vars(r)[e.parameterInfo.Name]
+		if !e.Info.Optional && !present {
+			return t, false, fmt.Errorf("missing required parameter %s", e.parameterInfo.Name)
 		}
 	case "header":
-		v, exists := r.Header[e.parameterInfo.Name]
-		if !e.Info.Optional && !exists {
-			return t, fmt.Errorf("missing required parameter %s", e.parameterInfo.Name)
+		var v []string
+		v, present = r.Header[e.parameterInfo.Name]
+		if !e.Info.Optional && !present {
+			return t, false, fmt.Errorf("missing required parameter %s", e.parameterInfo.Name)
+		}
+		if len(v) > 0 {
+			value = v[0]
 		}
-		value = v[0]
 	case "form":
-		if !e.Info.Optional && !r.Form.Has(e.parameterInfo.Name) {
-			return t, fmt.Errorf("missing required parameter %s", e.parameterInfo.Name)
+		if r.Form == nil {
+			// parses urlencoded bodies too, ErrNotMultipart is expected then
+			r.ParseMultipartForm(32 << 20)
+		}
+		present = r.Form.Has(e.parameterInfo.Name)
+		if !e.Info.Optional && !present {
+			return t, false, fmt.Errorf("missing required parameter %s", e.parameterInfo.Name)
 		}
 		value = r.FormValue(e.parameterInfo.Name)
 	case "body":
 		var body []byte
 		c, err := r.Body.Read(body)
 		if err != nil {
-			return t, err
+			return t, false, err
 		}
 		if c == 0 {
-			return t, fmt.Errorf("missing required body %s", e.parameterInfo.Name)
+			return t, false, fmt.Errorf("missing required body %s", e.parameterInfo.Name)
 		}
-		value = string(body)
+		value, present = string(body), true
 	case "jsonbody":
 		err := json.NewDecoder(r.Body).Decode(&t)
+		if err == io.EOF && e.Info.Optional {
+			return t, false, nil
+		}
 		if err != nil {
-			return t, err
+			return t, false, err
 		}
-		return t, nil
+		return t, true, nil
 	default:
-		return t, nil
+		return t, false, nil
 	}
+	if !present {
+		return t, false, nil
+	}
+	val, err := e.parse(value)
+	return val, true, err
+}
 
+func (e *EndpointParam[T]) parse(value string) (T, error) {
+	var t T
 	switch e.outType.Kind() {
 	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
 		parseInt, err := strconv.ParseInt(value, 10, 64)
@@ -202,6 +322,6 @@ func (e *EndpointParam[T]) ValueWithError(r *http.Request) (T, error) {
 
 func (e *EndpointParam[T]) Value(r *http.Request) T {
 	//return e.values[r]
-	val, _ := e.values.Get(fmt.Sprintf("%p", r))
+	val, _ := e.values.Get(reqkey.Of(r))
 	return val.(T)
 }
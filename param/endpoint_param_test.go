@@ -0,0 +1,77 @@
+package param_test
+
+import (
+	"fmt"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/param"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOptionalParams(t *testing.T) {
+	api := faust.New()
+	api.Get("/items", func(e *faust.Endpoint) http.HandlerFunc {
+		sort := param.Query[string](e, "sort").Optional().Enum("name", "date")
+		page := param.Query[int](e, "page").Optional()
+		limit := param.Header[int](e, "X-Limit").Optional()
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%q %d %d", sort.Value(r), page.Value(r), limit.Value(r))
+		}
+	})
+	tests := []struct {
+		query  string
+		header map[string]string
+		status int
+		body   string
+	}{
+		{"", nil, http.StatusOK, `"" 0 0`},
+		{"?sort=date&page=2", map[string]string{"X-Limit": "10"}, http.StatusOK, `"date" 2 10`},
+		{"?sort=", nil, http.StatusUnprocessableEntity, ""},
+		{"?sort=size", nil, http.StatusUnprocessableEntity, ""},
+		{"?page=", nil, http.StatusUnprocessableEntity, ""},
+		{"?page=two", nil, http.StatusUnprocessableEntity, ""},
+		{"", map[string]string{"X-Limit": "ten"}, http.StatusUnprocessableEntity, ""},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("GET", "/items"+test.query, nil)
+		for k, v := range test.header {
+			r.Header.Set(k, v)
+		}
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, r)
+		if w.Code != test.status || (test.body != "" && w.Body.String() != test.body) {
+			t.Errorf("%s %v: got status %d: %s", test.query, test.header, w.Code, w.Body)
+		}
+	}
+}
+
+func TestFormParams(t *testing.T) {
+	api := faust.New()
+	api.Post("/pets", func(e *faust.Endpoint) http.HandlerFunc {
+		name := param.Form[string](e, "name")
+		age := param.Form[int](e, "age").Optional()
+		return func(w http.ResponseWriter, r *http.Request) {
+			fmt.Fprintf(w, "%s %d", name.Value(r), age.Value(r))
+		}
+	})
+	tests := []struct {
+		body   string
+		status int
+		want   string
+	}{
+		{"name=rex&age=3", http.StatusOK, "rex 3"},
+		{"name=rex", http.StatusOK, "rex 0"},
+		{"age=3", http.StatusUnprocessableEntity, ""},
+	}
+	for _, test := range tests {
+		r := httptest.NewRequest("POST", "/pets", strings.NewReader(test.body))
+		r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, r)
+		if w.Code != test.status || (test.want != "" && w.Body.String() != test.want) {
+			t.Errorf("%s: got status %d: %s", test.body, w.Code, w.Body)
+		}
+	}
+}
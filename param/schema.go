@@ -0,0 +1,107 @@
+package param
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaOf describes t, struct fields are listed by their JSON names.
+func schemaOf(t reflect.Type) ParameterSchema {
+	return schemaOfType(t, map[reflect.Type]bool{})
+}
+
+func schemaOfType(t reflect.Type, seen map[reflect.Type]bool) ParameterSchema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	schema := ParameterSchema{
+		Type:   t.Kind().String(),
+		Format: t.Kind().String(),
+	}
+	switch t.Kind() {
+	case reflect.Struct:
+		if t == timeType {
+			schema.Type = "string"
+			schema.Format = "date-time"
+			return schema
+		}
+		schema.Name = t.Name()
+		// recursive types are only expanded once
+		if seen[t] {
+			return schema
+		}
+		seen[t] = true
+		defer delete(seen, t)
+		schema.Properties = map[string]ParameterSchema{}
+		addFields(&schema, t, seen)
+	case reflect.Slice, reflect.Array:
+		items := schemaOfType(t.Elem(), seen)
+		schema.Items = &items
+	case reflect.Map:
+		values := schemaOfType(t.Elem(), seen)
+		schema.AdditionalProperties = &values
+	}
+	return schema
+}
+
+func addFields(schema *ParameterSchema, t reflect.Type, seen map[reflect.Type]bool) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+		fieldType := field.Type
+		if field.Anonymous && name == "" {
+			for fieldType.Kind() == reflect.Pointer {
+				fieldType = fieldType.Elem()
+			}
+			if fieldType.Kind() == reflect.Struct {
+				addFields(schema, fieldType, seen)
+				continue
+			}
+		}
+		if !field.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		property := schemaOfType(field.Type, seen)
+		if enum, ok := field.Tag.Lookup("enum"); ok {
+			property.Enum = enumValues(property.Type, strings.Split(enum, ","))
+		}
+		schema.Properties[name] = property
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+}
+
+// enumValues converts the values of an enum struct tag, e.g. `enum:"a,b"`,
+// to the kind of the field.
+func enumValues(kind string, values []string) []any {
+	enum := make([]any, 0, len(values))
+	for _, value := range values {
+		value = strings.TrimSpace(value)
+		switch {
+		case strings.HasPrefix(kind, "int"), strings.HasPrefix(kind, "uint"), strings.HasPrefix(kind, "float"):
+			if number, err := strconv.ParseFloat(value, 64); err == nil {
+				enum = append(enum, number)
+				continue
+			}
+		case kind == "bool":
+			if b, err := strconv.ParseBool(value); err == nil {
+				enum = append(enum, b)
+				continue
+			}
+		}
+		enum = append(enum, value)
+	}
+	return enum
+}
@@ -0,0 +1,295 @@
+package faust
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// KeyFunc maps a request to the key it is rate limited under.
+type KeyFunc func(r *http.Request) string
+
+func KeyByIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func KeyByHeader(name string) KeyFunc {
+	return func(r *http.Request) string {
+		return r.Header.Get(name)
+	}
+}
+
+// RateLimitState is the per key state kept by a RateLimitStore, its meaning
+// depends on the RateLimitAlgorithm.
+type RateLimitState struct {
+	Value     float64
+	Previous  float64
+	Timestamp time.Time
+}
+
+// RateLimitStore keeps the rate limit state of every key. Update must apply
+// fn atomically, the state may be dropped once ttl has passed since the last
+// update. Keys are made of the method and route template of the endpoint and
+// the rate limit key, e.g. "GET /users/{id} 10.0.0.1", so instances sharing
+// a store share their limits.
+type RateLimitStore interface {
+	Update(key string, ttl time.Duration, fn func(state *RateLimitState)) error
+}
+
+type RateLimitResult struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration
+	RetryAfter time.Duration
+}
+
+type RateLimitAlgorithm interface {
+	Name() string
+	Take(state *RateLimitState, limit int, window time.Duration, now time.Time) RateLimitResult
+}
+
+var (
+	// TokenBucket refills limit tokens evenly over the window, allowing bursts
+	// of up to limit requests.
+	TokenBucket RateLimitAlgorithm = tokenBucket{}
+	// SlidingWindow approximates the number of requests in the last window by
+	// weighting the previous fixed window by its overlap.
+	SlidingWindow RateLimitAlgorithm = slidingWindow{}
+)
+
+type tokenBucket struct{}
+
+func (tokenBucket) Name() string {
+	return "token_bucket"
+}
+
+func (tokenBucket) Take(state *RateLimitState, limit int, window time.Duration, now time.Time) RateLimitResult {
+	rate := float64(limit) / float64(window)
+	if state.Timestamp.IsZero() {
+		state.Value = float64(limit)
+	} else {
+		state.Value = math.Min(float64(limit), state.Value+float64(now.Sub(state.Timestamp))*rate)
+	}
+	state.Timestamp = now
+
+	result := RateLimitResult{Limit: limit}
+	if state.Value >= 1 {
+		state.Value--
+		result.Allowed = true
+	} else {
+		result.RetryAfter = time.Duration((1 - state.Value) / rate)
+	}
+	result.Remaining = int(state.Value)
+	result.Reset = time.Duration((float64(limit) - state.Value) / rate)
+	return result
+}
+
+type slidingWindow struct{}
+
+func (slidingWindow) Name() string {
+	return "sliding_window"
+}
+
+func (slidingWindow) Take(state *RateLimitState, limit int, window time.Duration, now time.Time) RateLimitResult {
+	start := now.Truncate(window)
+	if !state.Timestamp.Equal(start) {
+		if start.Sub(state.Timestamp) == window {
+			state.Previous = state.Value
+		} else {
+			state.Previous = 0
+		}
+		state.Value = 0
+		state.Timestamp = start
+	}
+	elapsed := float64(now.Sub(start)) / float64(window)
+	estimate := state.Previous*(1-elapsed) + state.Value
+
+	result := RateLimitResult{Limit: limit, Reset: start.Add(window).Sub(now)}
+	if estimate+1 <= float64(limit) {
+		state.Value++
+		estimate++
+		result.Allowed = true
+	} else if state.Value+1 > float64(limit) || state.Previous == 0 {
+		result.RetryAfter = result.Reset
+	} else {
+		// the previous window has to slide out far enough to make room
+		needed := 1 - (float64(limit)-1-state.Value)/state.Previous
+		result.RetryAfter = time.Duration(needed*float64(window)) - now.Sub(start)
+	}
+	result.Remaining = int(math.Max(0, float64(limit)-math.Ceil(estimate)))
+	return result
+}
+
+type memoryEntry struct {
+	state   RateLimitState
+	expires time.Time
+}
+
+// MemoryRateLimitStore is an in process RateLimitStore.
+type MemoryRateLimitStore struct {
+	lock      sync.Mutex
+	entries   map[string]*memoryEntry
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		entries: map[string]*memoryEntry{},
+	}
+}
+
+func (s *MemoryRateLimitStore) Update(key string, ttl time.Duration, fn func(state *RateLimitState)) error {
+	now := time.Now()
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, entry := range s.entries {
+			if now.After(entry.expires) {
+				delete(s.entries, k)
+			}
+		}
+		s.lastSweep = now
+	}
+	entry, ok := s.entries[key]
+	if !ok {
+		entry = &memoryEntry{}
+		s.entries[key] = entry
+	}
+	fn(&entry.state)
+	entry.expires = now.Add(ttl)
+	return nil
+}
+
+type RateLimitPolicy struct {
+	Limit  int
+	Window time.Duration
+	// Key defaults to KeyByIP.
+	Key KeyFunc
+	// Algorithm defaults to TokenBucket.
+	Algorithm RateLimitAlgorithm
+	// Store defaults to a MemoryRateLimitStore of the root API, so separate
+	// APIs in a process don't share their limits.
+	Store RateLimitStore
+}
+
+func (p *RateLimitPolicy) MarshalJSON() ([]byte, error) {
+	return json.Marshal(map[string]any{
+		"limit":     p.Limit,
+		"window":    p.Window.String(),
+		"algorithm": p.algorithm().Name(),
+	})
+}
+
+func (p *RateLimitPolicy) algorithm() RateLimitAlgorithm {
+	if p.Algorithm == nil {
+		return TokenBucket
+	}
+	return p.Algorithm
+}
+
+// RateLimit limits the endpoint to limit requests per window for each key.
+func (e *Endpoint) RateLimit(limit int, window time.Duration, keyFunc KeyFunc) *Endpoint {
+	return e.RateLimitWith(RateLimitPolicy{Limit: limit, Window: window, Key: keyFunc})
+}
+
+func (e *Endpoint) RateLimitWith(policy RateLimitPolicy) *Endpoint {
+	e.rateLimit = &policy
+	return e
+}
+
+// RateLimit sets the default rate limit of the endpoints of the API and its
+// subrouters. Every endpoint is limited separately.
+func (api *API) RateLimit(limit int, window time.Duration, keyFunc KeyFunc) *API {
+	return api.RateLimitWith(RateLimitPolicy{Limit: limit, Window: window, Key: keyFunc})
+}
+
+func (api *API) RateLimitWith(policy RateLimitPolicy) *API {
+	api.rateLimit = &policy
+	return api
+}
+
+func (e *Endpoint) rateLimitPolicy() *RateLimitPolicy {
+	if e.rateLimit != nil {
+		return e.rateLimit
+	}
+	for a := e.api; a != nil; a = a.parent {
+		if a.rateLimit != nil {
+			return a.rateLimit
+		}
+	}
+	return nil
+}
+
+// limit takes a request from the endpoint's rate limit and writes the
+// RateLimit headers, it returns an error if the request has to be rejected.
+func (e *Endpoint) limit(w http.ResponseWriter, r *http.Request) error {
+	policy := e.rateLimitPolicy()
+	if policy == nil || policy.Limit <= 0 || policy.Window <= 0 {
+		return nil
+	}
+	keyFunc, store := policy.Key, policy.Store
+	if keyFunc == nil {
+		keyFunc = KeyByIP
+	}
+	if store == nil {
+		store = e.api.defaultRateLimitStore()
+	}
+
+	var result RateLimitResult
+	now := time.Now()
+	// the sliding window reads the state of the previous window
+	key := e.Method + " " + e.api.prefix() + e.Path + " " + keyFunc(r)
+	err := store.Update(key, 2*policy.Window, func(state *RateLimitState) {
+		result = policy.algorithm().Take(state, policy.Limit, policy.Window, now)
+	})
+	if err != nil {
+		// fail open, an unavailable store should not take the endpoint down
+		return nil
+	}
+
+	w.Header().Set("RateLimit-Policy", fmt.Sprintf("%d;w=%d", policy.Limit, seconds(policy.Window)))
+	w.Header().Set("RateLimit-Limit", strconv.Itoa(result.Limit))
+	w.Header().Set("RateLimit-Remaining", strconv.Itoa(result.Remaining))
+	w.Header().Set("RateLimit-Reset", strconv.Itoa(seconds(result.Reset)))
+	if result.Allowed {
+		return nil
+	}
+	fe := NewError(http.StatusTooManyRequests, "rate_limited", fmt.Errorf("rate limit of %d requests per %v exceeded", policy.Limit, policy.Window))
+	fe.Header = http.Header{"Retry-After": {strconv.Itoa(seconds(result.RetryAfter))}}
+	return fe
+}
+
+// prefix is the path the API is mounted under, including its parents.
+func (api *API) prefix() string {
+	if api.parent == nil {
+		return ""
+	}
+	return api.parent.prefix() + api.Path
+}
+
+func (api *API) defaultRateLimitStore() RateLimitStore {
+	root := api
+	for root.parent != nil {
+		root = root.parent
+	}
+	root.rateLimitStoreOnce.Do(func() {
+		root.rateLimitStore = NewMemoryRateLimitStore()
+	})
+	return root.rateLimitStore
+}
+
+// seconds rounds d up to whole seconds, it is at least 1 for any positive
+// duration.
+func seconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,141 @@
+package faust_test
+
+import (
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/param"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestTokenBucket(t *testing.T) {
+	var state faust.RateLimitState
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	take := func(at time.Duration) faust.RateLimitResult {
+		return faust.TokenBucket.Take(&state, 10, time.Minute, start.Add(at))
+	}
+	// a full bucket allows a burst of the limit
+	for i := 0; i < 10; i++ {
+		if result := take(0); !result.Allowed || result.Remaining != 9-i {
+			t.Fatalf("request %d: got %+v", i, result)
+		}
+	}
+	result := take(0)
+	if result.Allowed || result.RetryAfter != 6*time.Second {
+		t.Errorf("empty bucket: got %+v, want a retry after 6s", result)
+	}
+	// a token is refilled every 6s
+	if result := take(6 * time.Second); !result.Allowed {
+		t.Errorf("after 6s: got %+v", result)
+	}
+	if result := take(7 * time.Second); result.Allowed {
+		t.Errorf("after 7s: got %+v", result)
+	}
+	// the bucket doesn't fill beyond the limit
+	if result := take(time.Hour); !result.Allowed || result.Remaining != 9 {
+		t.Errorf("after an hour: got %+v", result)
+	}
+}
+
+func TestSlidingWindow(t *testing.T) {
+	var state faust.RateLimitState
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	take := func(at time.Duration) faust.RateLimitResult {
+		return faust.SlidingWindow.Take(&state, 10, time.Minute, start.Add(at))
+	}
+	allowed := func(at time.Duration, n int) int {
+		count := 0
+		for i := 0; i < n; i++ {
+			if take(at).Allowed {
+				count++
+			}
+		}
+		return count
+	}
+	if got := allowed(0, 15); got != 10 {
+		t.Errorf("first window: %d allowed, want 10", got)
+	}
+	if result := take(10 * time.Second); result.Allowed || result.RetryAfter != 50*time.Second {
+		t.Errorf("full window: got %+v, want a retry after 50s", result)
+	}
+	// half of the previous window still counts
+	if got := allowed(90*time.Second, 10); got != 5 {
+		t.Errorf("half way through the second window: %d allowed, want 5", got)
+	}
+	// the previous window is forgotten after a whole empty window
+	if got := allowed(4*time.Minute, 15); got != 10 {
+		t.Errorf("after an idle window: %d allowed, want 10", got)
+	}
+}
+
+type recordingStore struct {
+	*faust.MemoryRateLimitStore
+	keys []string
+	ttls []time.Duration
+}
+
+func (s *recordingStore) Update(key string, ttl time.Duration, fn func(state *faust.RateLimitState)) error {
+	s.keys = append(s.keys, key)
+	s.ttls = append(s.ttls, ttl)
+	return s.MemoryRateLimitStore.Update(key, ttl, fn)
+}
+
+func TestRateLimitSharedStore(t *testing.T) {
+	store := &recordingStore{MemoryRateLimitStore: faust.NewMemoryRateLimitStore()}
+	replica := func() *faust.API {
+		api := faust.New()
+		api.Subrouter("/users").Get("/{id}", func(e *faust.Endpoint) http.HandlerFunc {
+			param.Path[int](e, "id")
+			e.RateLimitWith(faust.RateLimitPolicy{Limit: 2, Window: time.Minute, Algorithm: faust.SlidingWindow, Store: store})
+			return func(w http.ResponseWriter, r *http.Request) {}
+		})
+		return api
+	}
+	first, second := replica(), replica()
+	for _, api := range []*faust.API{first, second} {
+		if w := send(api, "GET", "/users/1", nil); w.Code != http.StatusOK {
+			t.Fatalf("got status %d, want 200", w.Code)
+		}
+	}
+	w := send(first, "GET", "/users/2", nil)
+	if w.Code != http.StatusTooManyRequests {
+		t.Errorf("third request across replicas: got status %d, want 429", w.Code)
+	}
+	if w.Header().Get("Retry-After") == "" || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Errorf("got headers %v", w.Header())
+	}
+
+	for i, key := range store.keys {
+		if key != "GET /users/{id} 192.0.2.1" {
+			t.Errorf("got store key %q", key)
+		}
+		if store.ttls[i] != 2*time.Minute {
+			t.Errorf("got ttl %v, want two windows", store.ttls[i])
+		}
+	}
+}
+
+func TestRateLimitDefaultStore(t *testing.T) {
+	newAPI := func(window time.Duration) *faust.API {
+		api := faust.New().RateLimit(1, window, nil)
+		api.Get("/items", func(e *faust.Endpoint) http.HandlerFunc {
+			return func(w http.ResponseWriter, r *http.Request) {}
+		})
+		return api
+	}
+	first, second := newAPI(time.Minute), newAPI(time.Minute)
+	if w := send(first, "GET", "/items", nil); w.Code != http.StatusOK {
+		t.Fatalf("first API: got status %d, want 200", w.Code)
+	}
+	if w := send(second, "GET", "/items", nil); w.Code != http.StatusOK {
+		t.Errorf("second API: got status %d, the APIs share their limits", w.Code)
+	}
+	if w := send(first, "GET", "/items", nil); w.Code != http.StatusTooManyRequests {
+		t.Errorf("first API again: got status %d, want 429", w.Code)
+	}
+
+	w := send(newAPI(500*time.Millisecond), "GET", "/items", nil)
+	if policy := w.Header().Get("RateLimit-Policy"); policy != "1;w=1" {
+		t.Errorf("half second window: got RateLimit-Policy %q, want 1;w=1", policy)
+	}
+}
@@ -0,0 +1,67 @@
+package faust
+
+import (
+	"fmt"
+	"github.com/nokusukun/faust/internal/panics"
+	"net/http"
+	"runtime/debug"
+)
+
+// PanicError is a panic recovered while serving an endpoint.
+type PanicError struct {
+	Value    any
+	Stack    []byte
+	Endpoint string
+	Method   string
+	Path     string
+}
+
+func (p *PanicError) Error() string {
+	return fmt.Sprintf("panic serving %s %s: %v", p.Method, p.Path, p.Value)
+}
+
+func (e *Endpoint) panicError(value any) *PanicError {
+	if pe, ok := value.(*PanicError); ok {
+		return pe
+	}
+	return &PanicError{
+		Value:    value,
+		Stack:    debug.Stack(),
+		Endpoint: e.EndpointInfo.Name,
+		Method:   e.Method,
+		Path:     e.api.prefix() + e.Path,
+	}
+}
+
+// recover turns a panic of the endpoint into a 500 response, it has to be
+// deferred directly.
+func (e *Endpoint) recover(w http.ResponseWriter, r *http.Request) {
+	value := recover()
+	if value == nil {
+		return
+	}
+	if value == http.ErrAbortHandler {
+		panic(value)
+	}
+	pe := e.panicError(value)
+	e.reportPanic(r, pe)
+	e.fail(w, r, NewError(http.StatusInternalServerError, "internal_error", pe))
+}
+
+func (e *Endpoint) reportPanic(r *http.Request, pe *PanicError) {
+	panics.Observe(r.Context(), pe)
+	for a := e.api; a != nil; a = a.parent {
+		if a.OnPanic != nil {
+			a.OnPanic(r, pe)
+			return
+		}
+	}
+	e.api.logger().Error("panic serving request",
+		"method", pe.Method,
+		"route", pe.Path,
+		"name", pe.Endpoint,
+		"request_id", RequestIDFrom(r.Context()),
+		"panic", fmt.Sprint(pe.Value),
+		"stack", string(pe.Stack),
+	)
+}
@@ -0,0 +1,120 @@
+package faust
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/hex"
+	"net/http"
+	"time"
+)
+
+type RequestIDConfig struct {
+	// Header is read for an incoming request ID and set on the response, it
+	// defaults to X-Request-ID.
+	Header string
+	// Generate creates the ID of requests that don't bring a usable one, it
+	// defaults to NewUUID.
+	Generate func() string
+	// IgnoreIncoming always generates a new ID, e.g. for APIs exposed to
+	// untrusted clients.
+	IgnoreIncoming bool
+}
+
+// RequestID assigns an ID to every request to the endpoints of the API and its
+// subrouters. The ID is echoed in the response, added to error bodies and
+// access logs, and available to handlers through RequestIDFrom.
+func (api *API) RequestID(config RequestIDConfig) *API {
+	if config.Header == "" {
+		config.Header = "X-Request-ID"
+	}
+	if config.Generate == nil {
+		config.Generate = NewUUID
+	}
+	api.requestID = &config
+	return api
+}
+
+func (api *API) requestIDConfig() *RequestIDConfig {
+	for a := api; a != nil; a = a.parent {
+		if a.requestID != nil {
+			return a.requestID
+		}
+	}
+	return nil
+}
+
+// RequestIDFrom returns the ID faust assigned to the request, if any.
+func RequestIDFrom(ctx context.Context) string {
+	if state := stateFrom(ctx); state != nil {
+		return state.requestID
+	}
+	return ""
+}
+
+func (c *RequestIDConfig) resolve(w http.ResponseWriter, r *http.Request) string {
+	id := ""
+	if !c.IgnoreIncoming {
+		id = r.Header.Get(c.Header)
+	}
+	if !validRequestID(id) {
+		id = c.Generate()
+	}
+	w.Header().Set(c.Header, id)
+	return id
+}
+
+// validRequestID accepts reasonably sized IDs of printable ASCII characters,
+// so that incoming IDs can't be used to inject into logs.
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// NewUUID returns a random (version 4) UUID.
+func NewUUID() string {
+	var b [16]byte
+	rand.Read(b[:])
+	b[6] = b[6]&0x0f | 0x40
+	b[8] = b[8]&0x3f | 0x80
+	var out [36]byte
+	hex.Encode(out[0:8], b[0:4])
+	out[8] = '-'
+	hex.Encode(out[9:13], b[4:6])
+	out[13] = '-'
+	hex.Encode(out[14:18], b[6:8])
+	out[18] = '-'
+	hex.Encode(out[19:23], b[8:10])
+	out[23] = '-'
+	hex.Encode(out[24:], b[10:])
+	return string(out[:])
+}
+
+const crockford = "0123456789ABCDEFGHJKMNPQRSTVWXYZ"
+
+// NewULID returns a ULID, a lexicographically sortable ID made of a
+// millisecond timestamp and 80 random bits.
+func NewULID() string {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(time.Now().UnixMilli())<<16)
+	rand.Read(b[6:])
+
+	// 128 bits encode to 26 characters of 5 bits, the first one holding
+	// only the top 3 bits.
+	hi := binary.BigEndian.Uint64(b[:8])
+	lo := binary.BigEndian.Uint64(b[8:])
+	var out [26]byte
+	for i := 25; i >= 0; i-- {
+		out[i] = crockford[lo&0x1f]
+		lo = lo>>5 | hi<<59
+		hi >>= 5
+	}
+	return string(out[:])
+}
@@ -0,0 +1,87 @@
+package faust_test
+
+import (
+	"encoding/json"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/param"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+var (
+	uuidPattern = regexp.MustCompile(`^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`)
+	ulidPattern = regexp.MustCompile(`^[0-7][0-9A-HJKMNP-TV-Z]{25}$`)
+)
+
+func TestRequestID(t *testing.T) {
+	api := faust.New().RequestID(faust.RequestIDConfig{})
+	api.Get("/id", func(e *faust.Endpoint) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte(faust.RequestIDFrom(r.Context())))
+		}
+	})
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{"none", "", false},
+		{"incoming", "req-1", true},
+		{"longest", strings.Repeat("a", 128), true},
+		{"oversized", strings.Repeat("a", 129), false},
+		{"space", "req 1", false},
+		{"newline", "req\n1", false},
+		{"non ascii", "réq", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			header := map[string]string{}
+			if test.incoming != "" {
+				header["X-Request-ID"] = test.incoming
+			}
+			w := send(api, "GET", "/id", header)
+			id := w.Header().Get("X-Request-ID")
+			if id != w.Body.String() {
+				t.Errorf("got %q in the response header and %q from RequestIDFrom", id, w.Body)
+			}
+			if test.keep && id != test.incoming {
+				t.Errorf("got %q, want the incoming ID", id)
+			}
+			if !test.keep && !uuidPattern.MatchString(id) {
+				t.Errorf("got %q, want a generated UUID", id)
+			}
+		})
+	}
+}
+
+func TestRequestIDConfig(t *testing.T) {
+	api := faust.New().RequestID(faust.RequestIDConfig{Header: "X-Trace", Generate: faust.NewULID, IgnoreIncoming: true})
+	api.Get("/id", func(e *faust.Endpoint) http.HandlerFunc {
+		return func(w http.ResponseWriter, r *http.Request) {}
+	})
+	id := send(api, "GET", "/id", map[string]string{"X-Trace": "req-1"}).Header().Get("X-Trace")
+	if !ulidPattern.MatchString(id) {
+		t.Errorf("got %q, want a generated ULID", id)
+	}
+	if faust.NewULID() == faust.NewULID() {
+		t.Error("NewULID repeated an ID")
+	}
+}
+
+func TestRequestIDInErrors(t *testing.T) {
+	api := faust.New().RequestID(faust.RequestIDConfig{})
+	api.Get("/items/{id}", func(e *faust.Endpoint) http.HandlerFunc {
+		param.Path[int](e, "id")
+		return func(w http.ResponseWriter, r *http.Request) {}
+	})
+	w := send(api, "GET", "/items/abc", map[string]string{"X-Request-ID": "req-1"})
+	var body map[string]any
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil {
+		t.Fatalf("got status %d: %s", w.Code, w.Body)
+	}
+	if w.Code != http.StatusUnprocessableEntity || body["request_id"] != "req-1" {
+		t.Errorf("got status %d: %s", w.Code, w.Body)
+	}
+}
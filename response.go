@@ -0,0 +1,119 @@
+package faust
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+)
+
+// responseWriter records the status and size of a response.
+type responseWriter struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (rw *responseWriter) WriteHeader(code int) {
+	if rw.status == 0 {
+		rw.status = code
+	}
+	rw.ResponseWriter.WriteHeader(code)
+}
+
+func (rw *responseWriter) Write(p []byte) (int, error) {
+	if rw.status == 0 {
+		rw.status = http.StatusOK
+	}
+	n, err := rw.ResponseWriter.Write(p)
+	rw.bytes += int64(n)
+	return n, err
+}
+
+func (rw *responseWriter) Status() int {
+	if rw.status == 0 {
+		return http.StatusOK
+	}
+	return rw.status
+}
+
+func (rw *responseWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *responseWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	if h, ok := rw.ResponseWriter.(http.Hijacker); ok {
+		return h.Hijack()
+	}
+	return nil, nil, fmt.Errorf("response writer does not support hijacking")
+}
+
+func (rw *responseWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// paramValue is a parsed parameter value captured for logging.
+type paramValue struct {
+	In    string
+	Name  string
+	Value any
+}
+
+// requestState is shared by the layers serving a single request, it travels
+// in the request context so it survives middlewares replacing the request.
+type requestState struct {
+	lock      sync.Mutex
+	endpoint  *Endpoint
+	requestID string
+	params    []paramValue
+}
+
+type requestStateKey struct{}
+
+func stateFrom(ctx context.Context) *requestState {
+	state, _ := ctx.Value(requestStateKey{}).(*requestState)
+	return state
+}
+
+// ParamDescriber is implemented by parameters that can report where they
+// are read from, e.g. for metrics.
+type ParamDescriber interface {
+	ParamInfo() (in, name string, sensitive bool)
+}
+
+// ParamValuer is implemented by parameters that can report their parsed
+// value, e.g. for access logs.
+type ParamValuer interface {
+	ParamDescriber
+	ParamValue(r *http.Request) (any, bool)
+}
+
+// captureParams records the parsed parameter values of the request before
+// they are disposed.
+func (e *Endpoint) captureParams(r *http.Request) {
+	state := stateFrom(r.Context())
+	if state == nil {
+		return
+	}
+	for _, param := range e.Params {
+		valuer, ok := param.(ParamValuer)
+		if !ok {
+			continue
+		}
+		value, ok := valuer.ParamValue(r)
+		if !ok {
+			continue
+		}
+		in, name, sensitive := valuer.ParamInfo()
+		if sensitive {
+			value = redacted
+		}
+		state.lock.Lock()
+		state.params = append(state.params, paramValue{In: in, Name: name, Value: value})
+		state.lock.Unlock()
+	}
+}
@@ -0,0 +1,157 @@
+package faust
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type RouteParam struct {
+	In   string `json:"in"`
+	Name string `json:"name"`
+}
+
+// Route describes an endpoint as it is served, with its effective policies.
+type Route struct {
+	Method        string           `json:"method"`
+	Path          string           `json:"path"`
+	Name          string           `json:"name,omitempty"`
+	Params        []RouteParam     `json:"params,omitempty"`
+	Middlewares   []string         `json:"middlewares,omitempty"`
+	Tags          []string         `json:"tags,omitempty"`
+	Authorization *Requirements    `json:"authorization,omitempty"`
+	RateLimit     *RateLimitPolicy `json:"rate_limit,omitempty"`
+	MaxInFlight   int              `json:"max_in_flight,omitempty"`
+	QueueSize     int              `json:"queue,omitempty"`
+	Timeout       time.Duration    `json:"-"`
+	Endpoint      *Endpoint        `json:"-"`
+}
+
+func (r Route) MarshalJSON() ([]byte, error) {
+	type route Route
+	return json.Marshal(struct {
+		route
+		Timeout string `json:"timeout,omitempty"`
+	}{route: route(r), Timeout: durationString(r.Timeout)})
+}
+
+// Route describes the endpoint, see API.Routes.
+func (e *Endpoint) Route() Route {
+	route := Route{
+		Method:        e.Method,
+		Path:          e.api.prefix() + e.Path,
+		Name:          e.EndpointInfo.Name,
+		Tags:          e.tags(),
+		Authorization: e.Authorization,
+		RateLimit:     e.rateLimitPolicy(),
+		Timeout:       e.timeoutDuration(),
+		Endpoint:      e,
+	}
+	for _, param := range e.Params {
+		if describer, ok := param.(ParamDescriber); ok {
+			in, name, _ := describer.ParamInfo()
+			route.Params = append(route.Params, RouteParam{In: in, Name: name})
+		}
+	}
+	for _, middleware := range e.middlewares {
+		route.Middlewares = append(route.Middlewares, funcName(middleware))
+	}
+	if e.concurrency != nil {
+		route.MaxInFlight = e.concurrency.maxInFlight
+		route.QueueSize = e.concurrency.queueSize
+	}
+	return route
+}
+
+// Routes lists every endpoint of the API and its subrouters, sorted by path
+// and method.
+func (api *API) Routes() []Route {
+	var routes []Route
+	api.walk(func(e *Endpoint) {
+		routes = append(routes, e.Route())
+	})
+	sort.SliceStable(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+	return routes
+}
+
+// PrintRoutes writes the routes of the API as a table, e.g. to os.Stdout at
+// startup.
+func (api *API) PrintRoutes(w io.Writer) error {
+	return PrintRoutes(w, api.Routes())
+}
+
+// PrintRoutes writes routes as a table.
+func PrintRoutes(w io.Writer, routes []Route) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tNAME\tPARAMS\tMIDDLEWARES\tTAGS\tLIMITS")
+	for _, route := range routes {
+		var params []string
+		for _, param := range route.Params {
+			params = append(params, param.In+":"+param.Name)
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			route.Method,
+			route.Path,
+			orDash(route.Name),
+			orDash(strings.Join(params, " ")),
+			orDash(strings.Join(route.Middlewares, " ")),
+			orDash(strings.Join(route.Tags, " ")),
+			orDash(route.limits()),
+		)
+	}
+	return tw.Flush()
+}
+
+func (r Route) limits() string {
+	var limits []string
+	if r.RateLimit != nil {
+		limits = append(limits, fmt.Sprintf("rate=%d/%s", r.RateLimit.Limit, r.RateLimit.Window))
+	}
+	if r.MaxInFlight > 0 {
+		limits = append(limits, fmt.Sprintf("in-flight=%d", r.MaxInFlight))
+	}
+	if r.QueueSize > 0 {
+		limits = append(limits, fmt.Sprintf("queue=%d", r.QueueSize))
+	}
+	if r.Timeout > 0 {
+		limits = append(limits, fmt.Sprintf("timeout=%s", r.Timeout))
+	}
+	return strings.Join(limits, " ")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// DebugRoutes serves the routes of the API at /debug/routes, as JSON or as a
+// table when text/plain is accepted.
+func (api *API) DebugRoutes() *API {
+	api.checkNotBuilt()
+	api.Mux.HandleFunc("/debug/routes", func(w http.ResponseWriter, r *http.Request) {
+		routes := api.Routes()
+		if strings.Contains(r.Header.Get("Accept"), "text/plain") {
+			w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+			PrintRoutes(w, routes)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		err := json.NewEncoder(w).Encode(routes)
+		if err != nil {
+			api.logger().Error("encoding debug routes", "error", err)
+		}
+	}).Methods("GET")
+	return api
+}
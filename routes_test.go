@@ -0,0 +1,51 @@
+package faust_test
+
+import (
+	"bytes"
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/param"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func routesAPI() *faust.API {
+	api := faust.New().Tags("public").Timeout(time.Second)
+	api.Get("/health", noop)
+	users := api.Subrouter("/users").RateLimit(10, time.Minute, nil)
+	users.Get("/{id}", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Name("getUser").Middlewares(passThrough).Tags("users")
+		param.Path[int](e, "id")
+		param.Query[string](e, "fields")
+		return noop(e)
+	})
+	users.Delete("/{id}", func(e *faust.Endpoint) http.HandlerFunc {
+		e.RequireScopes("users:write").MaxInFlight(2).Queue(5, time.Second)
+		param.Path[int](e, "id")
+		return noop(e)
+	})
+	return api.DebugRoutes()
+}
+
+func TestPrintRoutes(t *testing.T) {
+	var b bytes.Buffer
+	if err := routesAPI().PrintRoutes(&b); err != nil {
+		t.Fatal(err)
+	}
+	golden(t, "routes.golden", b.String())
+}
+
+func TestDebugRoutes(t *testing.T) {
+	api := routesAPI()
+	w := send(api, "GET", "/debug/routes", map[string]string{"Accept": "text/plain"})
+	if w.Header().Get("Content-Type") != "text/plain; charset=utf-8" {
+		t.Errorf("got Content-Type %q", w.Header().Get("Content-Type"))
+	}
+	golden(t, "routes.golden", w.Body.String())
+
+	w = send(api, "GET", "/debug/routes", nil)
+	if w.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("got Content-Type %q", w.Header().Get("Content-Type"))
+	}
+	golden(t, "routes.json.golden", w.Body.String())
+}
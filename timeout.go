@@ -0,0 +1,194 @@
+package faust
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Timeout limits how long the endpoint may take to respond. The request
+// context is cancelled after d and the client gets a 503 if the handler has
+// not finished by then.
+func (e *Endpoint) Timeout(d time.Duration) *Endpoint {
+	e.timeout = d
+	return e
+}
+
+// Timeout sets the default timeout of the endpoints of the API and its
+// subrouters.
+func (api *API) Timeout(d time.Duration) *API {
+	api.timeout = d
+	return api
+}
+
+func (e *Endpoint) timeoutDuration() time.Duration {
+	if e.timeout > 0 {
+		return e.timeout
+	}
+	for a := e.api; a != nil; a = a.parent {
+		if a.timeout > 0 {
+			return a.timeout
+		}
+	}
+	return 0
+}
+
+// timeoutWriter buffers the response of a handler so that it can be
+// discarded if the handler does not finish in time. Once the handler flushes,
+// the buffered response is sent and later writes go straight to w.
+type timeoutWriter struct {
+	lock        sync.Mutex
+	ctx         context.Context
+	w           http.ResponseWriter
+	header      http.Header
+	body        bytes.Buffer
+	code        int
+	wroteHeader bool
+	flushed     bool
+	timedOut    bool
+}
+
+func (tw *timeoutWriter) Header() http.Header {
+	return tw.header
+}
+
+func (tw *timeoutWriter) Write(p []byte) (int, error) {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	if tw.timedOut || tw.ctx.Err() != nil {
+		return 0, http.ErrHandlerTimeout
+	}
+	if !tw.wroteHeader {
+		tw.writeHeaderLocked(http.StatusOK)
+	}
+	if tw.flushed {
+		return tw.w.Write(p)
+	}
+	return tw.body.Write(p)
+}
+
+func (tw *timeoutWriter) WriteHeader(code int) {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	if tw.timedOut || tw.wroteHeader || tw.ctx.Err() != nil {
+		return
+	}
+	tw.writeHeaderLocked(code)
+}
+
+func (tw *timeoutWriter) writeHeaderLocked(code int) {
+	tw.wroteHeader = true
+	tw.code = code
+}
+
+// Flush sends the response so far, the timeout can no longer replace it.
+func (tw *timeoutWriter) Flush() {
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	if tw.timedOut {
+		return
+	}
+	tw.sendLocked()
+	if f, ok := tw.w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// sendLocked copies the buffered response to w.
+func (tw *timeoutWriter) sendLocked() {
+	if !tw.flushed {
+		dst := tw.w.Header()
+		for k, v := range tw.header {
+			dst[k] = v
+		}
+		if !tw.wroteHeader {
+			tw.writeHeaderLocked(http.StatusOK)
+		}
+		tw.w.WriteHeader(tw.code)
+		tw.flushed = true
+	}
+	tw.w.Write(tw.body.Bytes())
+	tw.body.Reset()
+}
+
+// withTimeout runs next with a deadline on the request context and calls
+// release once next returns, which may be after the timeout response was
+// sent, so that the endpoint's concurrency limit counts abandoned handlers.
+// The handler writes into a buffer that is only copied to w if it finishes
+// in time, so that it never races the timeout response. Requests cancelled
+// by the client are left unanswered.
+func (e *Endpoint) withTimeout(w http.ResponseWriter, r *http.Request, next http.Handler, release func()) {
+	timeout := e.timeoutDuration()
+	if timeout <= 0 {
+		defer release()
+		next.ServeHTTP(w, r)
+		return
+	}
+	ctx, cancel := context.WithTimeout(r.Context(), timeout)
+	defer cancel()
+	r = r.WithContext(ctx)
+
+	tw := &timeoutWriter{ctx: ctx, w: w, header: http.Header{}}
+	// done receives the panic of the handler, or nil, unless it timed out
+	done := make(chan any, 1)
+	go func() {
+		var panicked any
+		defer func() {
+			release()
+			tw.lock.Lock()
+			defer tw.lock.Unlock()
+			if !tw.timedOut {
+				done <- panicked
+				return
+			}
+			if pe, ok := panicked.(*PanicError); ok {
+				e.reportPanic(r, pe)
+			}
+		}()
+		defer func() {
+			if p := recover(); p != nil {
+				panicked = p
+				if p != http.ErrAbortHandler {
+					// the stack is only available in the panicking goroutine
+					panicked = e.panicError(p)
+				}
+			}
+		}()
+		next.ServeHTTP(tw, r)
+	}()
+
+	var panicked any
+	select {
+	case panicked = <-done:
+	case <-ctx.Done():
+		tw.lock.Lock()
+		finished := false
+		select {
+		case panicked = <-done:
+			// the handler returned as the context ended, the response it
+			// started in time stands
+			finished = panicked != nil || tw.wroteHeader
+		default:
+		}
+		if !finished {
+			tw.timedOut = true
+			flushed := tw.flushed
+			tw.lock.Unlock()
+			// a client that went away is not answered
+			if !flushed && ctx.Err() == context.DeadlineExceeded {
+				e.fail(w, r, NewError(http.StatusServiceUnavailable, "timeout", fmt.Errorf("request timed out after %v", timeout)))
+			}
+			return
+		}
+		tw.lock.Unlock()
+	}
+	if panicked != nil {
+		panic(panicked)
+	}
+	tw.lock.Lock()
+	defer tw.lock.Unlock()
+	tw.sendLocked()
+}
@@ -0,0 +1,222 @@
+package faust_test
+
+import (
+	"bufio"
+	"context"
+	"github.com/nokusukun/faust"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestTimeout(t *testing.T) {
+	api := faust.New()
+	api.Get("/slow", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Timeout(10 * time.Millisecond)
+		return func(w http.ResponseWriter, r *http.Request) {
+			<-r.Context().Done()
+			w.Write([]byte("too late"))
+		}
+	})
+	w := send(api, "GET", "/slow", nil)
+	if w.Code != http.StatusServiceUnavailable || !strings.Contains(w.Body.String(), `"timeout"`) || strings.Contains(w.Body.String(), "too late") {
+		t.Errorf("got status %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestTimeoutKeepsConcurrencySlot(t *testing.T) {
+	api := faust.New()
+	var running, most int64
+	release := make(chan struct{})
+	api.Get("/slow", func(e *faust.Endpoint) http.HandlerFunc {
+		e.MaxInFlight(1).Timeout(5 * time.Millisecond)
+		return func(w http.ResponseWriter, r *http.Request) {
+			n := atomic.AddInt64(&running, 1)
+			defer atomic.AddInt64(&running, -1)
+			for {
+				m := atomic.LoadInt64(&most)
+				if n <= m || atomic.CompareAndSwapInt64(&most, m, n) {
+					break
+				}
+			}
+			// ignores the cancelled context
+			<-release
+		}
+	})
+	codes := map[int]int{}
+	for i := 0; i < 5; i++ {
+		codes[send(api, "GET", "/slow", nil).Code]++
+	}
+	if most := atomic.LoadInt64(&most); most != 1 {
+		t.Errorf("%d handlers ran at the same time with MaxInFlight(1)", most)
+	}
+	if codes[http.StatusServiceUnavailable] != 5 {
+		t.Errorf("got statuses %v, want 5 503s", codes)
+	}
+	if load := api.Load()[0]; load.InFlight != 1 {
+		t.Errorf("got %d in flight, want the abandoned handler", load.InFlight)
+	}
+	close(release)
+	for api.Load()[0].InFlight != 0 {
+		time.Sleep(time.Millisecond)
+	}
+	if w := send(api, "GET", "/slow", nil); w.Code != http.StatusOK {
+		t.Errorf("once the handler returned: got status %d, want 200", w.Code)
+	}
+}
+
+func TestTimeoutStreaming(t *testing.T) {
+	api := faust.New()
+	next := make(chan struct{})
+	api.Get("/stream", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Timeout(time.Second)
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/event-stream")
+			for i := 0; i < 3; i++ {
+				w.Write([]byte("event\n"))
+				w.(http.Flusher).Flush()
+				<-next
+			}
+		}
+	})
+	server := httptest.NewServer(api)
+	defer server.Close()
+	resp, err := http.Get(server.URL + "/stream")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Errorf("got Content-Type %q", resp.Header.Get("Content-Type"))
+	}
+	reader := bufio.NewReader(resp.Body)
+	for i := 0; i < 3; i++ {
+		// each event arrives before the handler is let to continue
+		line, err := reader.ReadString('\n')
+		if err != nil || line != "event\n" {
+			t.Fatalf("event %d: got %q, %v", i, line, err)
+		}
+		next <- struct{}{}
+	}
+	if rest, _ := io.ReadAll(reader); len(rest) > 0 {
+		t.Errorf("got %q after the events", rest)
+	}
+}
+
+func TestTimeoutAfterFlush(t *testing.T) {
+	api := faust.New()
+	api.Get("/stream", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Timeout(10 * time.Millisecond)
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("partial"))
+			w.(http.Flusher).Flush()
+			<-r.Context().Done()
+			w.Write([]byte("dropped"))
+		}
+	})
+	w := send(api, "GET", "/stream", nil)
+	if w.Code != http.StatusOK || w.Body.String() != "partial" {
+		t.Errorf("got status %d: %q, want the flushed response only", w.Code, w.Body)
+	}
+}
+
+func TestTimeoutHandlerFinishingAsCancelled(t *testing.T) {
+	// a single P lets the handler return before withTimeout sees the
+	// cancellation, so both are ready at once
+	defer runtime.GOMAXPROCS(runtime.GOMAXPROCS(1))
+	api := faust.New()
+	var cancel context.CancelFunc
+	api.Get("/racing", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Timeout(time.Second)
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.Write([]byte("done"))
+			cancel()
+		}
+	})
+	for i := 0; i < 20; i++ {
+		ctx, stop := context.WithCancel(context.Background())
+		cancel = stop
+		w := httptest.NewRecorder()
+		api.ServeHTTP(w, httptest.NewRequest("GET", "/racing", nil).WithContext(ctx))
+		stop()
+		if w.Code != http.StatusOK || w.Body.String() != "done" {
+			t.Fatalf("got status %d: %q, want the finished response", w.Code, w.Body)
+		}
+	}
+}
+
+func TestTimeoutClientGone(t *testing.T) {
+	api := faust.New()
+	started := make(chan struct{})
+	api.Get("/slow", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Timeout(time.Second)
+		return func(w http.ResponseWriter, r *http.Request) {
+			close(started)
+			<-r.Context().Done()
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-started
+		cancel()
+	}()
+	w := httptest.NewRecorder()
+	api.ServeHTTP(w, httptest.NewRequest("GET", "/slow", nil).WithContext(ctx))
+	if w.Body.Len() > 0 || w.Header().Get("Content-Type") != "" {
+		t.Errorf("a cancelled request was answered with %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestTimeoutPanicsAreReported(t *testing.T) {
+	api := faust.New()
+	var lock sync.Mutex
+	reported := 0
+	api.OnPanic = func(r *http.Request, err *faust.PanicError) {
+		lock.Lock()
+		reported++
+		lock.Unlock()
+	}
+	api.Get("/early", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Timeout(time.Second)
+		return func(w http.ResponseWriter, r *http.Request) {
+			panic("early")
+		}
+	})
+	api.Get("/racing", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Timeout(time.Millisecond)
+		return func(w http.ResponseWriter, r *http.Request) {
+			// panics as the timeout response is being written
+			<-r.Context().Done()
+			panic("racing")
+		}
+	})
+	if w := send(api, "GET", "/early", nil); w.Code != http.StatusInternalServerError {
+		t.Errorf("panic before the timeout: got status %d, want 500", w.Code)
+	}
+
+	const runs = 50
+	for i := 0; i < runs; i++ {
+		if w := send(api, "GET", "/racing", nil); w.Code != http.StatusInternalServerError && w.Code != http.StatusServiceUnavailable {
+			t.Errorf("got status %d", w.Code)
+		}
+	}
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		lock.Lock()
+		n := reported
+		lock.Unlock()
+		if n == runs+1 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d panics reported", n, runs+1)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
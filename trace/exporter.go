@@ -0,0 +1,52 @@
+package trace
+
+import (
+	"encoding/json"
+	"io"
+	"sync"
+)
+
+// InMemoryExporter keeps finished spans in memory, e.g. for tests.
+type InMemoryExporter struct {
+	lock  sync.Mutex
+	spans []SpanData
+}
+
+func NewInMemoryExporter() *InMemoryExporter {
+	return &InMemoryExporter{}
+}
+
+func (e *InMemoryExporter) Export(span SpanData) {
+	e.lock.Lock()
+	e.spans = append(e.spans, span)
+	e.lock.Unlock()
+}
+
+// Spans returns the exported spans in the order they ended.
+func (e *InMemoryExporter) Spans() []SpanData {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	return append([]SpanData{}, e.spans...)
+}
+
+func (e *InMemoryExporter) Reset() {
+	e.lock.Lock()
+	e.spans = nil
+	e.lock.Unlock()
+}
+
+// WriterExporter writes every span as a line of JSON, e.g. to os.Stdout.
+type WriterExporter struct {
+	lock    sync.Mutex
+	encoder *json.Encoder
+}
+
+func NewWriterExporter(w io.Writer) *WriterExporter {
+	return &WriterExporter{encoder: json.NewEncoder(w)}
+}
+
+func (e *WriterExporter) Export(span SpanData) {
+	e.lock.Lock()
+	e.encoder.Encode(span)
+	e.lock.Unlock()
+}
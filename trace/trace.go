@@ -0,0 +1,297 @@
+package trace
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+type TraceID [16]byte
+
+func (t TraceID) String() string {
+	return hex.EncodeToString(t[:])
+}
+
+func (t TraceID) IsValid() bool {
+	return t != TraceID{}
+}
+
+func (t TraceID) MarshalText() ([]byte, error) {
+	return []byte(t.String()), nil
+}
+
+type SpanID [8]byte
+
+func (s SpanID) String() string {
+	return hex.EncodeToString(s[:])
+}
+
+func (s SpanID) IsValid() bool {
+	return s != SpanID{}
+}
+
+func (s SpanID) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+const FlagSampled byte = 0x01
+
+// SpanContext identifies a span across process boundaries, as carried by the
+// W3C traceparent and tracestate headers.
+type SpanContext struct {
+	TraceID    TraceID `json:"trace_id"`
+	SpanID     SpanID  `json:"span_id"`
+	Flags      byte    `json:"flags"`
+	TraceState string  `json:"trace_state,omitempty"`
+	Remote     bool    `json:"remote,omitempty"`
+}
+
+func (sc SpanContext) IsValid() bool {
+	return sc.TraceID.IsValid() && sc.SpanID.IsValid()
+}
+
+// Traceparent formats the span context as a W3C traceparent header value.
+func (sc SpanContext) Traceparent() string {
+	return fmt.Sprintf("00-%s-%s-%02x", sc.TraceID, sc.SpanID, sc.Flags)
+}
+
+// ParseTraceparent parses a W3C traceparent header value.
+func ParseTraceparent(value string) (SpanContext, bool) {
+	var sc SpanContext
+	parts := strings.Split(strings.TrimSpace(value), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return sc, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return sc, false
+	}
+	if len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return sc, false
+	}
+	for _, part := range parts[:4] {
+		if !lowerHex(part) {
+			return sc, false
+		}
+	}
+	if _, err := hex.Decode(sc.TraceID[:], []byte(parts[1])); err != nil {
+		return sc, false
+	}
+	if _, err := hex.Decode(sc.SpanID[:], []byte(parts[2])); err != nil {
+		return sc, false
+	}
+	var flags [1]byte
+	if _, err := hex.Decode(flags[:], []byte(parts[3])); err != nil {
+		return sc, false
+	}
+	sc.Flags = flags[0]
+	sc.Remote = true
+	return sc, sc.IsValid()
+}
+
+// lowerHex reports whether s is made of lowercase hex digits only, as the
+// traceparent fields must be.
+func lowerHex(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if (s[i] < '0' || s[i] > '9') && (s[i] < 'a' || s[i] > 'f') {
+			return false
+		}
+	}
+	return true
+}
+
+type SpanKind string
+
+const (
+	KindInternal SpanKind = "internal"
+	KindServer   SpanKind = "server"
+	KindClient   SpanKind = "client"
+)
+
+type StatusCode string
+
+const (
+	StatusUnset StatusCode = "unset"
+	StatusOK    StatusCode = "ok"
+	StatusError StatusCode = "error"
+)
+
+// SpanData is the exported, read-only snapshot of a finished span.
+type SpanData struct {
+	Name          string         `json:"name"`
+	Kind          SpanKind       `json:"kind"`
+	SpanContext   SpanContext    `json:"span_context"`
+	Parent        SpanContext    `json:"parent"`
+	Start         time.Time      `json:"start"`
+	End           time.Time      `json:"end"`
+	Attributes    map[string]any `json:"attributes,omitempty"`
+	Status        StatusCode     `json:"status"`
+	StatusMessage string         `json:"status_message,omitempty"`
+}
+
+type Span struct {
+	lock   sync.Mutex
+	data   SpanData
+	ended  bool
+	tracer *Tracer
+}
+
+func (s *Span) SpanContext() SpanContext {
+	if s == nil {
+		return SpanContext{}
+	}
+	return s.data.SpanContext
+}
+
+func (s *Span) SetName(name string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.data.Name = name
+	s.lock.Unlock()
+}
+
+func (s *Span) SetAttribute(key string, value any) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.data.Attributes == nil {
+		s.data.Attributes = map[string]any{}
+	}
+	s.data.Attributes[key] = value
+	s.lock.Unlock()
+}
+
+func (s *Span) SetStatus(code StatusCode, message string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	s.data.Status = code
+	s.data.StatusMessage = message
+	s.lock.Unlock()
+}
+
+// RecordError marks the span as failed with err.
+func (s *Span) RecordError(err error) {
+	if err == nil {
+		return
+	}
+	s.SetStatus(StatusError, err.Error())
+}
+
+// End finishes the span and hands it to the exporter, only the first call
+// has an effect.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.data.End = time.Now()
+	data := s.data
+	if data.Attributes != nil {
+		data.Attributes = make(map[string]any, len(s.data.Attributes))
+		for k, v := range s.data.Attributes {
+			data.Attributes[k] = v
+		}
+	}
+	s.lock.Unlock()
+	if data.SpanContext.Flags&FlagSampled != 0 {
+		s.tracer.exporter.Export(data)
+	}
+}
+
+// Exporter receives every finished, sampled span.
+type Exporter interface {
+	Export(span SpanData)
+}
+
+type Tracer struct {
+	exporter Exporter
+}
+
+func NewTracer(exporter Exporter) *Tracer {
+	return &Tracer{exporter: exporter}
+}
+
+type spanKey struct{}
+
+type remoteKey struct{}
+
+// SpanFromContext returns the current span of ctx, or nil.
+func SpanFromContext(ctx context.Context) *Span {
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// SpanContextFromContext returns the span context of the current span, or
+// the remote span context extracted from incoming headers.
+func SpanContextFromContext(ctx context.Context) SpanContext {
+	if span := SpanFromContext(ctx); span != nil {
+		return span.SpanContext()
+	}
+	sc, _ := ctx.Value(remoteKey{}).(SpanContext)
+	return sc
+}
+
+// Start starts a span as a child of the current span of ctx. Root spans are
+// sampled, child spans inherit the sampling decision of their parent.
+func (t *Tracer) Start(ctx context.Context, name string, kind SpanKind) (context.Context, *Span) {
+	parent := SpanContextFromContext(ctx)
+	span := &Span{
+		tracer: t,
+		data: SpanData{
+			Name:   name,
+			Kind:   kind,
+			Parent: parent,
+			Start:  time.Now(),
+			Status: StatusUnset,
+		},
+	}
+	sc := &span.data.SpanContext
+	if parent.IsValid() {
+		sc.TraceID = parent.TraceID
+		sc.Flags = parent.Flags
+		sc.TraceState = parent.TraceState
+	} else {
+		rand.Read(sc.TraceID[:])
+		sc.Flags = FlagSampled
+	}
+	rand.Read(sc.SpanID[:])
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// Extract reads the W3C trace context headers into ctx, so that the next span
+// started from it continues the remote trace.
+func Extract(ctx context.Context, header http.Header) context.Context {
+	sc, ok := ParseTraceparent(header.Get("traceparent"))
+	if !ok {
+		return ctx
+	}
+	sc.TraceState = header.Get("tracestate")
+	return context.WithValue(ctx, remoteKey{}, sc)
+}
+
+// Inject writes the W3C trace context headers of the current span of ctx,
+// e.g. into an outgoing request.
+func Inject(ctx context.Context, header http.Header) {
+	sc := SpanContextFromContext(ctx)
+	if !sc.IsValid() {
+		return
+	}
+	header.Set("traceparent", sc.Traceparent())
+	if sc.TraceState != "" {
+		header.Set("tracestate", sc.TraceState)
+	}
+}
@@ -0,0 +1,107 @@
+package trace_test
+
+import (
+	"context"
+	"github.com/nokusukun/faust/trace"
+	"net/http"
+	"testing"
+)
+
+const (
+	traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	spanID  = "00f067aa0ba902b7"
+)
+
+func TestParseTraceparent(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		valid bool
+	}{
+		{"sampled", "00-" + traceID + "-" + spanID + "-01", true},
+		{"not sampled", "00-" + traceID + "-" + spanID + "-00", true},
+		{"surrounding space", " 00-" + traceID + "-" + spanID + "-01 ", true},
+		{"future version", "cc-" + traceID + "-" + spanID + "-01-extra", true},
+		{"version 00 with extra fields", "00-" + traceID + "-" + spanID + "-01-extra", false},
+		{"invalid version", "ff-" + traceID + "-" + spanID + "-01", false},
+		{"uppercase version", "0A-" + traceID + "-" + spanID + "-01", false},
+		{"uppercase trace ID", "00-4BF92F3577B34DA6A3CE929D0E0E4736-" + spanID + "-01", false},
+		{"uppercase span ID", "00-" + traceID + "-00F067AA0BA902B7-01", false},
+		{"uppercase flags", "00-" + traceID + "-" + spanID + "-0A", false},
+		{"zero trace ID", "00-00000000000000000000000000000000-" + spanID + "-01", false},
+		{"zero span ID", "00-" + traceID + "-0000000000000000-01", false},
+		{"short trace ID", "00-" + traceID[1:] + "-" + spanID + "-01", false},
+		{"not hex", "00-" + traceID[:31] + "g-" + spanID + "-01", false},
+		{"missing flags", "00-" + traceID + "-" + spanID, false},
+		{"empty", "", false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			sc, ok := trace.ParseTraceparent(test.value)
+			if ok != test.valid {
+				t.Fatalf("got valid %v, want %v", ok, test.valid)
+			}
+			if ok && (sc.TraceID.String() != traceID || sc.SpanID.String() != spanID || !sc.Remote) {
+				t.Errorf("got %+v", sc)
+			}
+		})
+	}
+	sc, _ := trace.ParseTraceparent("00-" + traceID + "-" + spanID + "-01")
+	if got := sc.Traceparent(); got != "00-"+traceID+"-"+spanID+"-01" {
+		t.Errorf("round trip: got %s", got)
+	}
+}
+
+func TestPropagation(t *testing.T) {
+	exporter := trace.NewInMemoryExporter()
+	tracer := trace.NewTracer(exporter)
+
+	incoming := http.Header{}
+	incoming.Set("traceparent", "00-"+traceID+"-"+spanID+"-01")
+	incoming.Set("tracestate", "vendor=1")
+	ctx, server := tracer.Start(trace.Extract(context.Background(), incoming), "server", trace.KindServer)
+	ctx, client := tracer.Start(ctx, "client", trace.KindClient)
+	outgoing := http.Header{}
+	trace.Inject(ctx, outgoing)
+	client.End()
+	server.End()
+
+	want := "00-" + traceID + "-" + client.SpanContext().SpanID.String() + "-01"
+	if got := outgoing.Get("traceparent"); got != want {
+		t.Errorf("got traceparent %s, want %s", got, want)
+	}
+	if got := outgoing.Get("tracestate"); got != "vendor=1" {
+		t.Errorf("got tracestate %q", got)
+	}
+	spans := exporter.Spans()
+	if len(spans) != 2 || spans[0].Name != "client" || spans[1].Name != "server" {
+		t.Fatalf("got spans %+v", spans)
+	}
+	if parent := spans[1].Parent; parent.SpanID.String() != spanID || !parent.Remote {
+		t.Errorf("server span: got parent %+v", parent)
+	}
+	if spans[0].Parent.SpanID != spans[1].SpanContext.SpanID {
+		t.Errorf("client span: got parent %v, want the server span", spans[0].Parent.SpanID)
+	}
+}
+
+func TestSampling(t *testing.T) {
+	exporter := trace.NewInMemoryExporter()
+	tracer := trace.NewTracer(exporter)
+
+	_, root := tracer.Start(context.Background(), "root", trace.KindServer)
+	root.End()
+	root.End()
+	if spans := exporter.Spans(); len(spans) != 1 || !spans[0].SpanContext.IsValid() || spans[0].Parent.IsValid() {
+		t.Errorf("root span: got %+v", spans)
+	}
+
+	exporter.Reset()
+	incoming := http.Header{}
+	incoming.Set("traceparent", "00-"+traceID+"-"+spanID+"-00")
+	_, span := tracer.Start(trace.Extract(context.Background(), incoming), "unsampled", trace.KindServer)
+	span.End()
+	if spans := exporter.Spans(); len(spans) != 0 {
+		t.Errorf("an unsampled trace was exported: %+v", spans)
+	}
+}
@@ -0,0 +1,99 @@
+package faust
+
+import (
+	"context"
+	"fmt"
+	"github.com/nokusukun/faust/trace"
+	"net/http"
+	"reflect"
+	"runtime"
+	"strings"
+)
+
+// Tracing starts a server span for every request to the endpoints of the API
+// and its subrouters, continuing the trace of incoming W3C traceparent
+// headers. Parameter parsing and every endpoint middleware get a child span.
+func (api *API) Tracing(tracer *trace.Tracer) *API {
+	api.tracer = tracer
+	return api
+}
+
+func (api *API) tracerFor() *trace.Tracer {
+	for a := api; a != nil; a = a.parent {
+		if a.tracer != nil {
+			return a.tracer
+		}
+	}
+	return nil
+}
+
+func (e *Endpoint) spanName() string {
+	if e.EndpointInfo.Name != "" {
+		return e.EndpointInfo.Name
+	}
+	return e.Method + " " + e.api.prefix() + e.Path
+}
+
+// startServerSpan starts the span of the request, the returned function ends
+// it once the response is known.
+func (e *Endpoint) startServerSpan(tracer *trace.Tracer, rw *responseWriter, r *http.Request) (*http.Request, func()) {
+	ctx, span := tracer.Start(trace.Extract(r.Context(), r.Header), e.spanName(), trace.KindServer)
+	span.SetAttribute("http.request.method", e.Method)
+	span.SetAttribute("http.route", e.api.prefix()+e.Path)
+	span.SetAttribute("url.path", r.URL.Path)
+	if id := RequestIDFrom(ctx); id != "" {
+		span.SetAttribute("request.id", id)
+	}
+	return r.WithContext(ctx), func() {
+		status := rw.Status()
+		span.SetAttribute("http.response.status_code", status)
+		if status >= 500 {
+			span.SetStatus(trace.StatusError, http.StatusText(status))
+		}
+		span.End()
+	}
+}
+
+// startSpan starts a child span of the request span, it returns a nil span
+// when the request isn't traced.
+func (e *Endpoint) startSpan(ctx context.Context, name string) (context.Context, *trace.Span) {
+	tracer := e.api.tracerFor()
+	if tracer == nil || trace.SpanFromContext(ctx) == nil {
+		return ctx, nil
+	}
+	return tracer.Start(ctx, name, trace.KindInternal)
+}
+
+func (e *Endpoint) traceMiddleware(name string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, span := e.startSpan(r.Context(), "middleware "+name)
+		if span == nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+		defer span.End()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+func paramSpanName(param IParam) string {
+	if describer, ok := param.(ParamDescriber); ok {
+		in, name, _ := describer.ParamInfo()
+		return fmt.Sprintf("param %s.%s", in, name)
+	}
+	return "param"
+}
+
+// funcName returns the package qualified name of a function, e.g.
+// "main.AuthMiddleware".
+func funcName(f any) string {
+	fn := runtime.FuncForPC(reflect.ValueOf(f).Pointer())
+	if fn == nil {
+		return "unknown"
+	}
+	name := fn.Name()
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	return name
+}
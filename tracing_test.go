@@ -0,0 +1,83 @@
+package faust_test
+
+import (
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/param"
+	"github.com/nokusukun/faust/trace"
+	"net/http"
+	"testing"
+)
+
+func passThrough(next http.Handler) http.Handler {
+	return next
+}
+
+func TestTracing(t *testing.T) {
+	exporter := trace.NewInMemoryExporter()
+	api := faust.New().Tracing(trace.NewTracer(exporter))
+	users := api.Subrouter("/users")
+	users.Get("/{id}", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Middlewares(passThrough)
+		param.Path[int](e, "id")
+		return func(w http.ResponseWriter, r *http.Request) {
+			// what an outgoing request of the handler would carry
+			trace.Inject(r.Context(), w.Header())
+		}
+	})
+	users.Delete("/{id}", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Name("deleteUser")
+		param.Path[int](e, "id")
+		return func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}
+	})
+
+	const parent = "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+	w := send(api, "GET", "/users/7", map[string]string{"traceparent": parent})
+	spans := exporter.Spans()
+	if len(spans) != 3 {
+		t.Fatalf("got %d spans, want 3: %+v", len(spans), spans)
+	}
+	server := spans[len(spans)-1]
+	if server.Name != "GET /users/{id}" || server.Kind != trace.KindServer || server.Status != trace.StatusUnset {
+		t.Errorf("got server span %+v", server)
+	}
+	if server.SpanContext.TraceID.String() != "4bf92f3577b34da6a3ce929d0e0e4736" || server.Parent.SpanID.String() != "00f067aa0ba902b7" {
+		t.Errorf("the server span doesn't continue the incoming trace: %+v", server)
+	}
+	attributes := map[string]any{
+		"http.request.method":       "GET",
+		"http.route":                "/users/{id}",
+		"url.path":                  "/users/7",
+		"http.response.status_code": http.StatusOK,
+	}
+	for key, value := range attributes {
+		if server.Attributes[key] != value {
+			t.Errorf("%s: got %v, want %v", key, server.Attributes[key], value)
+		}
+	}
+	children := map[string]trace.SpanData{}
+	for _, span := range spans[:2] {
+		children[span.Name] = span
+	}
+	middleware, parsing := children["middleware faust_test.passThrough"], children["param path.id"]
+	if middleware.Parent.SpanID != server.SpanContext.SpanID || !middleware.Parent.IsValid() {
+		t.Errorf("got middleware span %+v, want a child of the server span", middleware)
+	}
+	// parameters are parsed after the endpoint middlewares ran
+	if parsing.Parent.SpanID != middleware.SpanContext.SpanID || !parsing.Parent.IsValid() {
+		t.Errorf("got parameter span %+v, want a child of the middleware span", parsing)
+	}
+	// the handler sees the server span, or a child of it, as current
+	if sc, ok := trace.ParseTraceparent(w.Header().Get("traceparent")); !ok || sc.TraceID != server.SpanContext.TraceID {
+		t.Errorf("got traceparent %q in the handler", w.Header().Get("traceparent"))
+	}
+
+	exporter.Reset()
+	send(api, "DELETE", "/users/7", map[string]string{"traceparent": "00-00000000000000000000000000000000-00f067aa0ba902b7-01"})
+	spans = exporter.Spans()
+	server = spans[len(spans)-1]
+	if server.Name != "deleteUser" || server.Status != trace.StatusError || server.Parent.IsValid() {
+		t.Errorf("got server span %+v, want a failed root span", server)
+	}
+}
@@ -0,0 +1,128 @@
+package faust
+
+import (
+	"fmt"
+	"github.com/nokusukun/faust/internal/pathvars"
+	"net/url"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// ParamChecker is implemented by parameters that can validate a raw value
+// without a request, e.g. param.EndpointParam.
+type ParamChecker interface {
+	ParamCheck(value string) error
+}
+
+// URL builds the URL of the endpoint with the given name from its path
+// template, including the prefixes of its subrouters.
+func (api *API) URL(name string, params map[string]any, query url.Values) (string, error) {
+	var endpoint *Endpoint
+	api.root().walk(func(e *Endpoint) {
+		if endpoint == nil && e.EndpointInfo.Name == name {
+			endpoint = e
+		}
+	})
+	if endpoint == nil {
+		return "", fmt.Errorf("faust: no endpoint named %q", name)
+	}
+	return endpoint.URL(params, query)
+}
+
+// URLFor is URL with the path parameters taken from the fields of a struct,
+// named by their path tag, their json tag or else the field name.
+func URLFor[P any](api *API, name string, params P, query url.Values) (string, error) {
+	values := map[string]any{}
+	v := reflect.Indirect(reflect.ValueOf(params))
+	if v.Kind() != reflect.Struct {
+		return "", fmt.Errorf("faust: URL parameters must be a struct, got %T", params)
+	}
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		key := field.Name
+		if tag, _, _ := strings.Cut(field.Tag.Get("json"), ","); tag != "" && tag != "-" {
+			key = tag
+		}
+		if tag := field.Tag.Get("path"); tag != "" {
+			key = tag
+		}
+		values[key] = v.Field(i).Interface()
+	}
+	return api.URL(name, values, query)
+}
+
+// URL builds the URL of the endpoint, every variable of the path template
+// must be given a value that matches its pattern and is accepted by its path
+// parameter.
+func (e *Endpoint) URL(params map[string]any, query url.Values) (string, error) {
+	template := e.api.prefix() + e.Path
+	checkers := map[string]ParamChecker{}
+	for _, param := range e.Params {
+		describer, ok := param.(ParamDescriber)
+		if !ok {
+			continue
+		}
+		if in, name, _ := describer.ParamInfo(); in == "path" {
+			if checker, ok := param.(ParamChecker); ok {
+				checkers[name] = checker
+			}
+		}
+	}
+
+	var err error
+	used := map[string]bool{}
+	path := pathvars.Replace(template, func(v pathvars.Variable) string {
+		if err != nil {
+			return ""
+		}
+		name, pattern := v.Name, v.Pattern
+		param, ok := params[name]
+		if !ok {
+			err = fmt.Errorf("%s %s: missing path parameter %q", e.Method, template, name)
+			return ""
+		}
+		used[name] = true
+		value := fmt.Sprint(param)
+		if checker, ok := checkers[name]; ok {
+			if checkErr := checker.ParamCheck(value); checkErr != nil {
+				err = fmt.Errorf("%s %s: invalid path parameter %q: %w", e.Method, template, name, checkErr)
+				return ""
+			}
+		}
+		if pattern == "" {
+			pattern = "[^/]+"
+		}
+		if matched, _ := regexp.MatchString("^(?:"+pattern+")$", value); !matched {
+			err = fmt.Errorf("%s %s: path parameter %q does not match %s", e.Method, template, name, pattern)
+			return ""
+		}
+		// patterns may span several segments, only the segments are escaped
+		segments := strings.Split(value, "/")
+		for i, segment := range segments {
+			segments[i] = url.PathEscape(segment)
+		}
+		return strings.Join(segments, "/")
+	})
+	if err != nil {
+		return "", err
+	}
+	var unknown []string
+	for name := range params {
+		if !used[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return "", fmt.Errorf("%s %s: unknown path parameters %s", e.Method, template, strings.Join(unknown, ", "))
+	}
+	if len(query) > 0 {
+		path += "?" + query.Encode()
+	}
+	return path, nil
+}
@@ -0,0 +1,95 @@
+package faust_test
+
+import (
+	"github.com/nokusukun/faust"
+	"github.com/nokusukun/faust/param"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func urlsAPI() *faust.API {
+	api := faust.New()
+	orgs := api.Subrouter("/orgs/{org}")
+	orgs.Get("/users/{id:[0-9]+}", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Name("getUser")
+		param.Path[string](e, "org")
+		param.Path[int](e, "id")
+		return func(w http.ResponseWriter, r *http.Request) {}
+	})
+	api.Get("/files/{path:.+}", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Name("getFile")
+		param.Path[string](e, "path")
+		return func(w http.ResponseWriter, r *http.Request) {}
+	})
+	api.Get("/codes/{code:[a-z]{3}}", func(e *faust.Endpoint) http.HandlerFunc {
+		e.Name("getCode")
+		param.Path[string](e, "code")
+		return func(w http.ResponseWriter, r *http.Request) {}
+	})
+	return api
+}
+
+func TestURL(t *testing.T) {
+	api := urlsAPI()
+	tests := []struct {
+		name   string
+		params map[string]any
+		query  url.Values
+		want   string
+	}{
+		{"getUser", map[string]any{"org": "acme", "id": 7}, nil, "/orgs/acme/users/7"},
+		{"getUser", map[string]any{"org": "a b", "id": 7}, url.Values{"page": {"2"}}, "/orgs/a%20b/users/7?page=2"},
+		{"getFile", map[string]any{"path": "docs/read me.md"}, nil, "/files/docs/read%20me.md"},
+		{"getCode", map[string]any{"code": "abc"}, nil, "/codes/abc"},
+	}
+	for _, test := range tests {
+		got, err := api.URL(test.name, test.params, test.query)
+		if err != nil || got != test.want {
+			t.Errorf("%s %v: got %q, %v, want %q", test.name, test.params, got, err, test.want)
+		}
+	}
+	// a subrouter finds the endpoints of the whole API
+	sub := api.Subrouters[0]
+	if got, err := sub.URL("getFile", map[string]any{"path": "a"}, nil); err != nil || got != "/files/a" {
+		t.Errorf("from a subrouter: got %q, %v", got, err)
+	}
+}
+
+func TestURLErrors(t *testing.T) {
+	api := urlsAPI()
+	tests := []struct {
+		name   string
+		params map[string]any
+		want   string
+	}{
+		{"deleteUser", nil, `no endpoint named "deleteUser"`},
+		{"getUser", map[string]any{"id": 7}, `missing path parameter "org"`},
+		{"getUser", map[string]any{"org": "acme", "id": "seven"}, `invalid path parameter "id"`},
+		{"getUser", map[string]any{"org": "acme", "id": -7}, `path parameter "id" does not match [0-9]+`},
+		{"getUser", map[string]any{"org": "acme", "id": 7, "extra": 1}, `unknown path parameters extra`},
+		{"getCode", map[string]any{"code": "abcd"}, `path parameter "code" does not match [a-z]{3}`},
+	}
+	for _, test := range tests {
+		got, err := api.URL(test.name, test.params, nil)
+		if err == nil || !strings.Contains(err.Error(), test.want) {
+			t.Errorf("%s %v: got %q, %v, want an error containing %q", test.name, test.params, got, err, test.want)
+		}
+	}
+}
+
+func TestURLFor(t *testing.T) {
+	api := urlsAPI()
+	type user struct {
+		Org     string `json:"org"`
+		ID      int    `path:"id"`
+		ignored bool
+	}
+	if got, err := faust.URLFor(api, "getUser", user{Org: "acme", ID: 7}, nil); err != nil || got != "/orgs/acme/users/7" {
+		t.Errorf("got %q, %v", got, err)
+	}
+	if _, err := faust.URLFor(api, "getUser", 7, nil); err == nil {
+		t.Error("got no error for parameters that aren't a struct")
+	}
+}